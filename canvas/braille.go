@@ -0,0 +1,113 @@
+package canvas
+
+import (
+	. "math"
+	"math/bits"
+	. "tri/geom"
+)
+
+// Bit for each dot in a braille character, indexed by [row][column]
+// See: https://en.wikipedia.org/wiki/Braille_Patterns
+var brailleDots = [4][2]uint8{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// A cell's worth of braille dots, 2 wide and 4 tall
+type brailleCell struct {
+	Dots  uint8
+	Depth [4][2]float64
+	Color Color
+	// Depth of the dot the colour was taken from
+	nearest float64
+}
+
+func (b *brailleCell) Rune() rune {
+	return rune(0x2800 + int(b.Dots))
+}
+
+// Size of the braille dot grid covering the canvas
+func (c *Canvas) BrailleSize() (int, int) {
+	return c.Width * 2, c.Height * 4
+}
+
+func (c *Canvas) clearBraille() {
+	for i := range c.braille {
+		c.braille[i] = brailleCell{}
+	}
+}
+
+// Draw a line as braille dots. Coords are -1.0 to +1.0
+func (c *Canvas) DrawBrailleLine3(line Line3, color Color) {
+	w, h := c.BrailleSize()
+	hw, hh := float64(w)/2, float64(h)/2
+
+	x0, y0, z0 := Floor(line[0].X()*hw+hw), Floor(line[0].Y()*hh+hh), line[0].Z()
+	x1, y1, z1 := Floor(line[1].X()*hw+hw), Floor(line[1].Y()*hh+hh), line[1].Z()
+
+	steps := Max(Abs(x1-x0), Abs(y1-y0))
+	if steps == 0 {
+		c.SetBrailleDot(int(x0), int(y0), Min(z0, z1), color)
+		return
+	}
+
+	for i := 0.0; i <= steps; i++ {
+		t := i / steps
+		x := Round(x0 + (x1-x0)*t)
+		y := Round(y0 + (y1-y0)*t)
+		z := z0 + (z1-z0)*t
+		c.SetBrailleDot(int(x), int(y), z, color)
+	}
+}
+
+// Turn on a single braille dot, if nothing nearer is in the way
+func (c *Canvas) SetBrailleDot(x, y int, depth float64, color Color) {
+	w, h := c.BrailleSize()
+	if x < 0 || y < 0 || x >= w || y >= h {
+		return
+	}
+
+	cellX, cellY := x/2, y/4
+	col, row := x%2, y%4
+
+	// Hidden behind a pixel
+	pixels := c.pixelsPerCell()
+	if depth >= c.DepthAt(cellX, cellY*pixels+row*pixels/4) {
+		return
+	}
+
+	cell := &c.braille[c.positionToIndex(cellX, cellY)]
+	bit := brailleDots[row][col]
+	if cell.Dots&bit != 0 && depth >= cell.Depth[row][col] {
+		return
+	}
+
+	if cell.Dots == 0 || depth <= cell.nearest {
+		cell.Color = color
+		cell.nearest = depth
+	}
+	cell.Dots |= bit
+	cell.Depth[row][col] = depth
+}
+
+// Draws any braille dots on top of a packed cell
+func (c *Canvas) overlayBraille(x, y int, cell Cell) Cell {
+	braille := &c.braille[c.positionToIndex(x, y)]
+	if braille.Dots == 0 {
+		return cell
+	}
+
+	if cell.Sprite == '▀' {
+		// Can't show both halves behind the dots, so keep the one with most dots over it
+		if bits.OnesCount8(braille.Dots&0x1b) > bits.OnesCount8(braille.Dots&0xe4) {
+			cell.Bg = cell.Fg
+		}
+	}
+	cell.Sprite = braille.Rune()
+	cell.Fg = braille.Color
+	cell.Depth = Min(cell.Depth, braille.nearest)
+
+	return cell
+}
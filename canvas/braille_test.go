@@ -0,0 +1,81 @@
+package canvas
+
+import (
+	"testing"
+	. "tri/geom"
+)
+
+func TestSetBrailleDot(t *testing.T) {
+	// Dots are numbered down the left column, then the right, with the bottom row last
+	tests := []struct {
+		x, y   int
+		sprite rune
+	}{
+		{0, 0, '⠁'}, {0, 1, '⠂'}, {0, 2, '⠄'}, {0, 3, '⡀'},
+		{1, 0, '⠈'}, {1, 1, '⠐'}, {1, 2, '⠠'}, {1, 3, '⢀'},
+	}
+
+	for _, test := range tests {
+		canvas := NewCanvas(2, 2)
+		canvas.Clear()
+		// In the second cell down, to check the dot lands in the right cell
+		canvas.SetBrailleDot(test.x, test.y+4, 0.5, 0xff00ff00)
+
+		if cell := canvas.CellAt(0, 1); cell.Sprite != test.sprite || cell.Fg != 0xff00ff00 {
+			t.Errorf("Dot %d,%d: expected %c, got %c with colour %08x", test.x, test.y, test.sprite, cell.Sprite, uint32(cell.Fg))
+		}
+		if cell := canvas.CellAt(0, 0); cell.Sprite != ' ' {
+			t.Errorf("Dot %d,%d: expected the cell above to be empty, got %c", test.x, test.y, cell.Sprite)
+		}
+	}
+}
+
+func TestBrailleDotsShareCell(t *testing.T) {
+	canvas := NewCanvas(1, 1)
+	canvas.Clear()
+	canvas.SetBrailleDot(0, 0, 0.6, 0xffff0000)
+	canvas.SetBrailleDot(1, 3, 0.4, 0xff0000ff)
+	canvas.SetBrailleDot(1, 1, 0.8, 0xff00ff00)
+
+	// Both dots are shown, in the colour of the nearest one
+	cell := canvas.CellAt(0, 0)
+	if cell.Sprite != '⢑' || cell.Fg != 0xff0000ff || cell.Depth != 0.4 {
+		t.Errorf("Expected ⢑ in blue at depth 0.4, got %c in %08x at %v", cell.Sprite, uint32(cell.Fg), cell.Depth)
+	}
+}
+
+func TestBrailleDotDepth(t *testing.T) {
+	canvas := NewCanvas(2, 1)
+	canvas.Clear()
+	canvas.Set(0, 0, Cell{Bg: 0xffffffff, Depth: 0.5, Sprite: ' '})
+
+	// Behind a pixel, and in front of one
+	canvas.SetBrailleDot(0, 0, 0.7, 0xffff0000)
+	canvas.SetBrailleDot(2, 0, 0.7, 0xffff0000)
+	if cell := canvas.CellAt(0, 0); cell.Sprite != ' ' {
+		t.Errorf("Expected the dot behind the pixel to be hidden, got %c", cell.Sprite)
+	}
+	if cell := canvas.CellAt(1, 0); cell.Sprite != '⠁' {
+		t.Errorf("Expected the dot in front of nothing to be shown, got %c", cell.Sprite)
+	}
+
+	// A further dot in the same place doesn't replace a nearer one
+	canvas.SetBrailleDot(2, 0, 0.2, 0xff0000ff)
+	canvas.SetBrailleDot(2, 0, 0.9, 0xff00ff00)
+	if cell := canvas.CellAt(1, 0); cell.Fg != 0xff0000ff || cell.Depth != 0.2 {
+		t.Errorf("Expected the nearest dot to be kept, got %08x at %v", uint32(cell.Fg), cell.Depth)
+	}
+}
+
+func TestDrawBrailleLine3Clipped(t *testing.T) {
+	canvas := NewCanvas(2, 1)
+	canvas.Clear()
+
+	// Across the top row of dots, starting and ending well off the canvas
+	canvas.DrawBrailleLine3(Line3{{-3, -1, 0.5}, {3, -1, 0.5}}, 0xffffffff)
+	for x := 0; x < 2; x++ {
+		if cell := canvas.CellAt(x, 0); cell.Sprite != '⠉' {
+			t.Errorf("Cell %d: expected the top row of dots, got %c", x, cell.Sprite)
+		}
+	}
+}
@@ -28,23 +28,52 @@ func (t *Triangle) ToFloat() TriangleFloat {
 	}
 }
 
+// How 3D pixels are mapped onto terminal cells
+type PixelMode uint8
+
+const (
+	// One pixel per cell, drawn as a coloured space
+	CellPixels PixelMode = iota
+	// Two pixels per cell, stacked vertically and drawn with half block characters
+	HalfBlockPixels
+)
+
+// Width and Height are measured in terminal cells. The back buffer is
+// measured in pixels, which depending on the PixelMode may be smaller than a cell.
 type Canvas struct {
-	Width  int
-	Height int
-	front  []Cell
-	back   []Cell
-	mux    sync.Mutex
+	Width   int
+	Height  int
+	mode    PixelMode
+	front   []Cell
+	back    []Cell
+	braille []brailleCell
+	// Applied to a copy of the back buffer when presenting
+	postProcess    PostProcess
+	presentOptions PresentOptions
+	mux            sync.Mutex
 }
 
 func NewCanvas(width, height int) Canvas {
 	return Canvas{
-		Width:  width,
-		Height: height,
-		front:  make([]Cell, width*height),
-		back:   make([]Cell, width*height),
+		Width:   width,
+		Height:  height,
+		mode:    CellPixels,
+		front:   make([]Cell, width*height),
+		back:    make([]Cell, width*height),
+		braille: make([]brailleCell, width*height),
+		presentOptions: PresentOptions{
+			EraseRuns: true,
+		},
 	}
 }
 
+// Chooses which escape sequences Present can use
+func (c *Canvas) SetPresentOptions(options PresentOptions) {
+	c.Lock()
+	c.presentOptions = options
+	c.Unlock()
+}
+
 func (c *Canvas) Lock() {
 	c.mux.Lock()
 }
@@ -53,8 +82,39 @@ func (c *Canvas) Unlock() {
 	c.mux.Unlock()
 }
 
+func (c *Canvas) PixelMode() PixelMode {
+	return c.mode
+}
+
+// Changes how pixels are mapped to cells. Wipes out what's already drawn.
+func (c *Canvas) SetPixelMode(mode PixelMode) {
+	c.Lock()
+	c.mode = mode
+	c.front = make([]Cell, c.Width*c.Height)
+	c.back = make([]Cell, c.PixelWidth()*c.PixelHeight())
+	c.braille = make([]brailleCell, c.Width*c.Height)
+	c.Unlock()
+}
+
+// Number of pixels stacked vertically in each cell
+func (c *Canvas) pixelsPerCell() int {
+	if c.mode == HalfBlockPixels {
+		return 2
+	}
+	return 1
+}
+
+func (c *Canvas) PixelWidth() int {
+	return c.Width
+}
+
+func (c *Canvas) PixelHeight() int {
+	return c.Height * c.pixelsPerCell()
+}
+
+// Checks a pixel position against the size of the back buffer
 func (c *Canvas) IsOutOfBounds(x, y int) bool {
-	return x < 0 || y < 0 || x >= c.Width || y >= c.Height
+	return x < 0 || y < 0 || x >= c.PixelWidth() || y >= c.PixelHeight()
 }
 
 func (c *Canvas) Set(x, y int, cell Cell) {
@@ -79,8 +139,9 @@ func (c *Canvas) Get(x, y int) *Cell {
 	return c.GetBack(x, y)
 }
 
+// Gets the cell at a cell position as it was last presented
 func (c *Canvas) GetFront(x, y int) *Cell {
-	if c.IsOutOfBounds(x, y) {
+	if x < 0 || y < 0 || x >= c.Width || y >= c.Height {
 		return nil
 	}
 	idx := c.positionToIndex(x, y)
@@ -107,7 +168,21 @@ func (c *Canvas) Resize(width, height int) {
 	c.Height = height
 	// FIXME Don't wipe out what's already drawn
 	c.front = make([]Cell, width*height)
-	c.back = make([]Cell, width*height)
+	c.back = make([]Cell, c.PixelWidth()*c.PixelHeight())
+	c.braille = make([]brailleCell, c.Width*c.Height)
+	c.Unlock()
+}
+
+// Sprite that never matches a real cell, for Invalidate
+const invalidSprite rune = -1
+
+// Forgets what's on the terminal, so the next Present draws every cell.
+// Use it when something else has drawn over the screen.
+func (c *Canvas) Invalidate() {
+	c.Lock()
+	for i := range c.front {
+		c.front[i] = Cell{Sprite: invalidSprite}
+	}
 	c.Unlock()
 }
 
@@ -124,9 +199,10 @@ func (c *Canvas) ClearWithCell(cell Cell) {
 	for i := range c.back {
 		c.back[i] = cell
 	}
+	c.clearBraille()
 }
 
-// Draw one canvas onto another
+// Draw one canvas onto another. Positions are in cells.
 func (c *Canvas) DrawCanvas(dstX, dstY int, other *Canvas) {
 
 	w := other.Width
@@ -140,29 +216,42 @@ func (c *Canvas) DrawCanvas(dstX, dstY int, other *Canvas) {
 	if rh > 0 {
 		h -= rh
 	}
+
+	dstPixels := c.pixelsPerCell()
+	srcPixels := other.pixelsPerCell()
 	for y := 0; y < h; y++ {
 		for x := 0; x < w; x++ {
-			dst := c.Get(dstX+x, dstY+y)
-			src := other.Get(x, y)
+			for sub := 0; sub < dstPixels; sub++ {
+				dst := c.Get(dstX+x, (dstY+y)*dstPixels+sub)
+				src := other.Get(x, y*srcPixels+sub*srcPixels/dstPixels)
 
-			*dst = dst.Blend(*src)
+				*dst = dst.Blend(*src)
+			}
 		}
 	}
 }
 
+// Draw text starting at a cell position, one character per cell.
+// Anything off the edge of the canvas is cut off.
 func (c *Canvas) DrawText(dstX, dstY int, text string) {
-	for i, char := range text {
-		dst := c.Get(dstX+i, dstY)
-		*dst = dst.Blend(Cell{
-			Sprite: char,
-		})
+	pixels := c.pixelsPerCell()
+	x := dstX
+	for _, char := range text {
+		for sub := 0; sub < pixels; sub++ {
+			if dst := c.Get(x, dstY*pixels+sub); dst != nil {
+				*dst = dst.Blend(Cell{
+					Sprite: char,
+				})
+			}
+		}
+		x++
 	}
 }
 
 func (c *Canvas) DrawLine3D(start, end Point3, cell Cell) {
 	// Center and scale coordinates
-	hw := float64(c.Width) / 2
-	hh := float64(c.Height) / 2
+	hw := float64(c.PixelWidth()) / 2
+	hh := float64(c.PixelHeight()) / 2
 	startPos := [2]int{
 		int(start[0]*hw + hw),
 		int(start[1]*hh + hh),
@@ -210,7 +299,7 @@ func (c *Canvas) DrawDeepLine(line Line3, cell Cell) {
 		depth := z0
 		if c.DepthAt(int(x0), int(y0)) > depth {
 			cell.Depth = depth
-			c.Set(int(x0), int(y0), cell)
+			c.plot(int(x0), int(y0), cell)
 		}
 
 		if int(x0) == int(x1) && int(y0) == int(y1) {
@@ -231,6 +320,21 @@ func (c *Canvas) DrawDeepLine(line Line3, cell Cell) {
 	}
 }
 
+// Set a pixel, or blend it with what's already there if it's translucent.
+// Translucent pixels don't hide anything drawn after them.
+func (c *Canvas) plot(x, y int, cell Cell) {
+	if cell.Bg.Alpha() >= 1.0 {
+		c.Set(x, y, cell)
+		return
+	}
+
+	dst := c.Get(x, y)
+	if dst == nil {
+		return
+	}
+	dst.Bg = dst.Bg.Blend(cell.Bg)
+}
+
 func (c *Canvas) DrawLine(start, end [2]int, cell Cell) {
 	startF := [2]float64{float64(start[0]), float64(start[1])}
 	endF := [2]float64{float64(end[0]), float64(end[1])}
@@ -312,119 +416,14 @@ func (c *Canvas) fillFlatTopTriangle(tri TriangleFloat, cell Cell) {
 	}
 }
 
-func isTriangleOnScreen(tri Triangle3) bool {
-	return tri.IntersectsBox2(Box2{Point2{-1, -1}, Point2{1, 1}})
-}
-func isTriangleOffScreen(tri Triangle3) bool {
-	return !isTriangleOnScreen(tri)
-}
-
-// Triangle coords are -1.0 to +1.0
-// Vertex order: [top, br, bl]
-func (c *Canvas) fillFlatBottomTriangle3(tri Triangle3, cell Cell) {
-	if isTriangleOffScreen(tri) {
-		return
-	}
-
-	// Translate to pixel coords
-	p0 := c.Point3ToPoint2(tri[0]).Floor()
-	p1 := c.Point3ToPoint2(tri[1]).Floor()
-	p2 := c.Point3ToPoint2(tri[2]).Floor()
-
-	dy := p1.Y() - p0.Y()
-	slope0 := (p1.X() - p0.X()) / dy
-	slope1 := (p2.X() - p0.X()) / dy
-	zSlope0 := (tri[1].Z() - tri[0].Z()) / dy
-	zSlope1 := (tri[2].Z() - tri[0].Z()) / dy
-
-	x0 := p0.X()
-	x1 := p0.X()
-	z0 := tri[0].Z()
-	z1 := tri[0].Z()
-
-	for y := p0.Y(); y <= p1.Y(); y++ {
-		var sx0, sx1 float64
-		if slope0 < slope1 {
-			sx0 = Floor(x0)
-			sx1 = Floor(x1)
-		} else {
-			sx0 = Floor(x0)
-			sx1 = Floor(x1)
-		}
-
-		line := Line3{Point3{sx0, y, z0}, Point3{sx1, y, z1}}
-		c.DrawDeepLine(line, cell)
-		x0 += slope0
-		x1 += slope1
-		z0 += zSlope0
-		z1 += zSlope1
-	}
-}
-
-// Vertex order: [tl, tr, b]
-func (c *Canvas) fillFlatTopTriangle3(tri Triangle3, cell Cell) {
-	if isTriangleOffScreen(tri) {
-		return
-	}
-
-	p0 := c.Point3ToPoint2(tri[0]).Floor()
-	p1 := c.Point3ToPoint2(tri[1]).Floor()
-	p2 := c.Point3ToPoint2(tri[2]).Floor()
-
-	dy := p2.Y() - p0.Y()
-	slope0 := (p2.X() - p0.X()) / dy
-	slope1 := (p2.X() - p1.X()) / dy
-	zSlope0 := (tri[0].Z() - tri[2].Z()) / dy
-	zSlope1 := (tri[1].Z() - tri[2].Z()) / dy
-
-	x0 := float64(p2.X())
-	x1 := float64(p2.X())
-	z0 := tri[2].Z()
-	z1 := tri[2].Z()
-
-	for y := p2.Y(); y >= p0.Y(); y-- {
-		var sx0, sx1 float64
-		if slope0 > slope1 {
-			sx0 = Floor(x0)
-			sx1 = Floor(x1)
-		} else {
-			sx0 = Floor(x0)
-			sx1 = Floor(x1)
-		}
-
-		line := Line3{Point3{sx0, y, z0}, Point3{sx1, y, z1}}
-		c.DrawDeepLine(line, cell)
-		x0 -= slope0
-		x1 -= slope1
-		z0 += zSlope0
-		z1 += zSlope1
-	}
-}
-
 func (c *Canvas) DrawTriangle3(tri Triangle3, cell Cell) {
-	// Sort by Y axis
-	sort.SliceStable(tri[:], func(i, j int) bool {
-		return tri[i].Y() < tri[j].Y()
+	c.RasterizeTriangle([3]Vertex{
+		{Position: tri[0], W: 1},
+		{Position: tri[1], W: 1},
+		{Position: tri[2], W: 1},
+	}, func(*Fragment) (Cell, bool) {
+		return cell, true
 	})
-
-	if tri[1].Y() == tri[2].Y() {
-		c.fillFlatBottomTriangle3(tri, cell)
-
-	} else if tri[0].Y() == tri[1].Y() {
-		c.fillFlatTopTriangle3(tri, cell)
-
-	} else {
-		dy := (tri[1].Y() - tri[0].Y()) / (tri[2].Y() - tri[0].Y())
-
-		midVert := Point3{
-			tri[0].X() + dy*(tri[2].X()-tri[0].X()),
-			tri[1].Y(),
-			tri[0].Z() + dy*(tri[2].Z()-tri[0].Z()),
-		}
-
-		c.fillFlatBottomTriangle3(Triangle3{tri[0], midVert, tri[1]}, cell)
-		c.fillFlatTopTriangle3(Triangle3{tri[1], midVert, tri[2]}, cell)
-	}
 }
 
 func (c *Canvas) DrawWireTriangle3(tri Triangle3, cell Cell) {
@@ -440,8 +439,8 @@ func (c *Canvas) DrawWireTriangle3(tri Triangle3, cell Cell) {
 }
 
 func (c *Canvas) ScreenPoint3ToCellPoint3(point Point3) Point3 {
-	hw := float64(c.Width) / 2
-	hh := float64(c.Height) / 2
+	hw := float64(c.PixelWidth()) / 2
+	hh := float64(c.PixelHeight()) / 2
 
 	x := point.X()*hw + hw
 	y := point.Y()*hh + hh
@@ -450,8 +449,8 @@ func (c *Canvas) ScreenPoint3ToCellPoint3(point Point3) Point3 {
 }
 
 func (c *Canvas) Point3ToPoint2(point Point3) Point2 {
-	hw := float64(c.Width) / 2
-	hh := float64(c.Height) / 2
+	hw := float64(c.PixelWidth()) / 2
+	hh := float64(c.PixelHeight()) / 2
 
 	x := point.X()*hw + hw
 	y := point.Y()*hh + hh
@@ -460,8 +459,8 @@ func (c *Canvas) Point3ToPoint2(point Point3) Point2 {
 }
 
 func (c *Canvas) Point2ToCoord(point Point2) [2]int {
-	hw := float64(c.Width) / 2
-	hh := float64(c.Height) / 2
+	hw := float64(c.PixelWidth()) / 2
+	hh := float64(c.PixelHeight()) / 2
 
 	x := int(point.X()*hw + hw)
 	y := int(point.Y()*hh + hh)
@@ -470,8 +469,8 @@ func (c *Canvas) Point2ToCoord(point Point2) [2]int {
 }
 
 func (c *Canvas) Point3ToCoord(point Point3) [2]int {
-	hw := float64(c.Width) / 2
-	hh := float64(c.Height) / 2
+	hw := float64(c.PixelWidth()) / 2
+	hh := float64(c.PixelHeight()) / 2
 
 	x := int(point.X()*hw + hw)
 	y := int(point.Y()*hh + hh)
@@ -481,8 +480,8 @@ func (c *Canvas) Point3ToCoord(point Point3) [2]int {
 
 func (c *Canvas) DrawVectorTriangle(tri TriangleFloat, cell Cell) {
 	// Center and scale coordinates
-	hw := float64(c.Width) / 2
-	hh := float64(c.Height) / 2
+	hw := float64(c.PixelWidth()) / 2
+	hh := float64(c.PixelHeight()) / 2
 	c.DrawTriangle(
 		Triangle{
 			[2]int{
@@ -528,7 +527,104 @@ func (c *Canvas) DrawTriangle(tri Triangle, cell Cell) {
 	}
 }
 
-func (c *Canvas) Present(term *Terminal) {
+// Gets the cell that will be presented at a cell position
+func (c *Canvas) CellAt(x, y int) Cell {
+	if x < 0 || y < 0 || x >= c.Width || y >= c.Height {
+		return Cell{}
+	}
+	return c.packCell(c.back, x, y)
+}
+
+// Builds the cell that will be presented from the pixels and braille dots covering it.
+// Pixels are normally the back buffer, or a post processed copy of it.
+func (c *Canvas) packCell(pixels []Cell, x, y int) Cell {
+	return c.overlayBraille(x, y, c.packPixels(pixels, x, y))
+}
+
+// Copies the back buffer with the post process applied
+func (c *Canvas) processPixels(mode ColorMode) []Cell {
+	pixels := make([]Cell, len(c.back))
+	copy(pixels, c.back)
+	if !c.postProcess.WholeCells() || c.mode != HalfBlockPixels {
+		c.postProcess.Process(pixels, c.PixelWidth(), c.PixelHeight(), mode)
+		return pixels
+	}
+
+	// Characters take up a whole cell, so each pair of pixels is processed as one
+	width := c.PixelWidth()
+	cells := make([]Cell, c.Width*c.Height)
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			cells[x+y*c.Width] = blendPixels(&pixels[x+y*2*width], &pixels[x+(y*2+1)*width])
+		}
+	}
+	c.postProcess.Process(cells, c.Width, c.Height, mode)
+
+	// Both halves get the same cell, so it's packed as it is
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			pixels[x+y*2*width] = cells[x+y*c.Width]
+			pixels[x+(y*2+1)*width] = cells[x+y*c.Width]
+		}
+	}
+	return pixels
+}
+
+// A single pixel covering the same cell as two stacked pixels. Text keeps its colours.
+func blendPixels(top, bottom *Cell) Cell {
+	if top.Sprite != ' ' {
+		return *top
+	}
+	if bottom.Sprite != ' ' {
+		return *bottom
+	}
+	// Halves every channel, then adds back the bit they both lost
+	bg := (top.Bg>>1)&0x7f7f7f7f + (bottom.Bg>>1)&0x7f7f7f7f + top.Bg&bottom.Bg&0x01010101
+	return Cell{
+		Fg:     top.Fg,
+		Bg:     bg,
+		Depth:  Min(top.Depth, bottom.Depth),
+		Sprite: ' ',
+	}
+}
+
+// Packs the pixels covered by a cell into a single cell
+func (c *Canvas) packPixels(pixels []Cell, x, y int) Cell {
+	width := c.PixelWidth()
+	if c.mode != HalfBlockPixels {
+		return pixels[x+y*width]
+	}
+
+	top := &pixels[x+y*2*width]
+	bottom := &pixels[x+(y*2+1)*width]
+
+	// Text can't be split, so it takes its colours from whichever half it was drawn in
+	if top.Sprite != ' ' {
+		return *top
+	}
+	if bottom.Sprite != ' ' {
+		return *bottom
+	}
+
+	if top.Bg == bottom.Bg {
+		return Cell{
+			Fg:     top.Fg,
+			Bg:     top.Bg,
+			Depth:  top.Depth,
+			Sprite: ' ',
+		}
+	}
+
+	return Cell{
+		Fg:     top.Bg,
+		Bg:     bottom.Bg,
+		Depth:  Min(top.Depth, bottom.Depth),
+		Sprite: '▀',
+	}
+}
+
+// Sends the cells that changed since the last frame to the terminal
+func (c *Canvas) Present(term *Terminal) FrameStats {
 	width, height := c.Width, c.Height
 	if term.Width() < width {
 		width = term.Width()
@@ -537,40 +633,76 @@ func (c *Canvas) Present(term *Terminal) {
 		height = term.Height()
 	}
 
-	cursorX, cursorY := -1, -1
-	var cursorColor string = ""
+	e := c.encodeFrame(term.ColorMode(), width, height, width == term.Width())
+	term.Lock()
+	defer term.Unlock()
+	term.BeginFrame()
+	term.WriteBytes(e.Bytes())
+	term.EndFrame()
+	return e.stats
+}
+
+// Encodes the changes since the last frame, in the top left width x height cells,
+// and updates the front buffer to match
+func (c *Canvas) encodeFrame(mode ColorMode, width, height int, fullWidth bool) *encoder {
+	pixels := c.back
+	if c.postProcess != nil {
+		pixels = c.processPixels(mode)
+	}
+
+	e := newEncoder(mode, width, fullWidth, c.presentOptions)
+	row := make([]Cell, width)
 	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			backCell := c.GetBack(x, y)
-			frontCell := c.GetFront(x, y)
+		for x := range row {
+			row[x] = c.packCell(pixels, x, y)
+			if mode == MonoColor {
+				row[x] = row[x].monochrome()
+			}
+		}
 
-			if backCell == nil || frontCell == nil || backCell == frontCell {
-				continue
+		for x := 0; x < width; {
+			frontCell := c.GetFront(x, y)
+			backCell := row[x]
+
+			// A half block can be drawn either way up. Pick whichever avoids redrawing
+			// the cell or changing colour.
+			if backCell.Sprite == '▀' && !backCell.looksLike(frontCell) {
+				flipped := backCell.flipHalfBlock()
+				if flipped.looksLike(frontCell) || e.hasColors(&flipped) {
+					backCell = flipped
+				}
 			}
-			if frontCell.Fg == backCell.Fg && frontCell.Bg == backCell.Bg && backCell.Sprite == frontCell.Sprite {
+			if backCell.looksLike(frontCell) {
+				x++
 				continue
 			}
 
-			if x != cursorX || y != cursorY {
-				cursorX = x
-				cursorY = y
-				term.MoveTo(Position{X: x, Y: y})
+			// Draw every identical cell that follows at once, including unchanged
+			// ones in the middle, since skipping over them costs bytes too
+			count := 1
+			for x+count < width {
+				next := row[x+count]
+				if !next.looksLike(&backCell) {
+					next = next.flipHalfBlock()
+				}
+				if !next.looksLike(&backCell) {
+					break
+				}
+				count++
 			}
-
-			// Send colour only if it has changed
-			color := backCell.Ansi24BitColor()
-			if color != cursorColor {
-				cursorColor = color
-				term.Write(color)
+			for count > 1 && c.GetFront(x+count-1, y).looksLike(&backCell) {
+				count--
 			}
 
-			*frontCell = *backCell
-			// Write pixel ascii
-			term.WriteRune(frontCell.Sprite)
-			cursorX = x + 1
+			e.draw(x, y, &backCell, count)
+			for i := 0; i < count; i++ {
+				*c.GetFront(x+i, y) = backCell
+			}
+			x += count
 		}
 	}
-	term.Flush()
+
+	return e
 }
 
 func (c *Canvas) positionToIndex(x, y int) int {
@@ -0,0 +1,22 @@
+package canvas
+
+import "testing"
+
+func TestDrawTextClipped(t *testing.T) {
+	canvas := NewCanvas(5, 2)
+	canvas.Clear()
+
+	// Runs off the right edge, with a character that takes more than one byte
+	canvas.DrawText(2, 1, "héllo")
+	canvas.DrawText(-1, 0, "xyz")
+	canvas.DrawText(0, 2, "below")
+
+	rows := []string{"yz   ", "  hél"}
+	for y, row := range rows {
+		for x, expected := range []rune(row) {
+			if cell := canvas.CellAt(x, y); cell.Sprite != expected {
+				t.Errorf("Cell %d,%d: expected %q, got %q", x, y, expected, cell.Sprite)
+			}
+		}
+	}
+}
@@ -0,0 +1,349 @@
+// Helpers for testing what gets drawn to a canvas
+package canvastest
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	. "tri/canvas"
+	. "tri/geom"
+)
+
+// Depth is rounded to this many decimal places, so tiny floating point
+// differences don't fail tests
+const depthPrecision = 3
+
+// Most differences listed in a report, before the rest are summarised
+const maxReportedCells = 20
+
+// What a cell looks like in a golden file
+type GoldenCell struct {
+	Sprite rune
+	Fg     Color
+	Bg     Color
+	Depth  float64
+}
+
+func (c GoldenCell) String() string {
+	return fmt.Sprintf("%q fg=%08x bg=%08x depth=%v", c.Sprite, uint32(c.Fg), uint32(c.Bg), c.Depth)
+}
+
+// A grid of cells, as presented to the terminal
+type GoldenFrame struct {
+	Width  int
+	Height int
+	Cells  []GoldenCell
+}
+
+// Captures the cells a canvas would present
+func NewGoldenFrame(c *Canvas) GoldenFrame {
+	frame := GoldenFrame{
+		Width:  c.Width,
+		Height: c.Height,
+		Cells:  make([]GoldenCell, 0, c.Width*c.Height),
+	}
+
+	scale := math.Pow(10, depthPrecision)
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			cell := c.CellAt(x, y)
+			frame.Cells = append(frame.Cells, GoldenCell{
+				Sprite: cell.Sprite,
+				Fg:     cell.Fg,
+				Bg:     cell.Bg,
+				Depth:  math.Round(cell.Depth*scale) / scale,
+			})
+		}
+	}
+
+	return frame
+}
+
+func (f *GoldenFrame) At(x, y int) GoldenCell {
+	return f.Cells[x+y*f.Width]
+}
+
+// Serialises the frame as text, with a row of the frame on each line. Sprites are quoted,
+// colours are numbered in a legend of foreground and background, and depths come last:
+//
+//	size 3 1
+//	sprites
+//	"ab "
+//	colours
+//	0 0 1
+//	legend
+//	0 ffffffff 00000000
+//	1 ffffffff ff2222cc
+//	depths
+//	0.500 0.500 1000000.000
+func (f *GoldenFrame) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "size %d %d\n", f.Width, f.Height)
+
+	b.WriteString("sprites\n")
+	for y := 0; y < f.Height; y++ {
+		row := make([]rune, f.Width)
+		for x := range row {
+			row[x] = f.At(x, y).Sprite
+		}
+		b.WriteString(strconv.Quote(string(row)) + "\n")
+	}
+
+	// Colours are numbered in the order they're first used
+	var legend []goldenColors
+	numbers := map[goldenColors]int{}
+	colours := make([]int, len(f.Cells))
+	for i, cell := range f.Cells {
+		pair := goldenColors{cell.Fg, cell.Bg}
+		number, ok := numbers[pair]
+		if !ok {
+			number = len(legend)
+			numbers[pair] = number
+			legend = append(legend, pair)
+		}
+		colours[i] = number
+	}
+	digits := len(strconv.Itoa(len(legend) - 1))
+
+	b.WriteString("colours\n")
+	f.writeRows(&b, func(i int) string {
+		return fmt.Sprintf("%*d", digits, colours[i])
+	})
+	b.WriteString("legend\n")
+	for number, pair := range legend {
+		fmt.Fprintf(&b, "%*d %08x %08x\n", digits, number, uint32(pair.fg), uint32(pair.bg))
+	}
+	b.WriteString("depths\n")
+	f.writeRows(&b, func(i int) string {
+		return fmt.Sprintf("%.*f", depthPrecision, f.Cells[i].Depth)
+	})
+
+	return b.String()
+}
+
+type goldenColors struct {
+	fg, bg Color
+}
+
+// Writes a line for each row of the frame, with the cells separated by spaces
+func (f *GoldenFrame) writeRows(b *strings.Builder, cell func(i int) string) {
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			if x > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(cell(x + y*f.Width))
+		}
+		b.WriteByte('\n')
+	}
+}
+
+// Reads a frame written by String
+func ParseGoldenFrame(text string) (GoldenFrame, error) {
+	frame := GoldenFrame{}
+	r := goldenReader{scanner: bufio.NewScanner(strings.NewReader(text))}
+
+	line, err := r.next()
+	if err != nil {
+		return frame, err
+	}
+	if _, err := fmt.Sscanf(line, "size %d %d", &frame.Width, &frame.Height); err != nil {
+		return frame, r.errorf("%v", err)
+	}
+	frame.Cells = make([]GoldenCell, frame.Width*frame.Height)
+
+	if err := r.expect("sprites"); err != nil {
+		return frame, err
+	}
+	for y := 0; y < frame.Height; y++ {
+		line, err := r.next()
+		if err != nil {
+			return frame, err
+		}
+		row, err := strconv.Unquote(line)
+		if err != nil {
+			return frame, r.errorf("sprites aren't quoted: %v", err)
+		}
+		sprites := []rune(row)
+		if len(sprites) != frame.Width {
+			return frame, r.errorf("expected %d sprites, got %d", frame.Width, len(sprites))
+		}
+		for x, sprite := range sprites {
+			frame.Cells[x+y*frame.Width].Sprite = sprite
+		}
+	}
+
+	if err := r.expect("colours"); err != nil {
+		return frame, err
+	}
+	colours := make([]int, len(frame.Cells))
+	err = r.readRows(&frame, func(i int, field string) (err error) {
+		colours[i], err = strconv.Atoi(field)
+		return err
+	})
+	if err != nil {
+		return frame, err
+	}
+
+	if err := r.expect("legend"); err != nil {
+		return frame, err
+	}
+	var legend []goldenColors
+	for {
+		line, err := r.next()
+		if err != nil {
+			return frame, err
+		}
+		if line == "depths" {
+			break
+		}
+		var number int
+		var fg, bg uint32
+		if _, err := fmt.Sscanf(line, "%d %x %x", &number, &fg, &bg); err != nil {
+			return frame, r.errorf("%v", err)
+		}
+		if number != len(legend) {
+			return frame, r.errorf("expected colour %d, got %d", len(legend), number)
+		}
+		legend = append(legend, goldenColors{Color(fg), Color(bg)})
+	}
+	for i, number := range colours {
+		if number < 0 || number >= len(legend) {
+			return frame, fmt.Errorf("colour %d isn't in the legend", number)
+		}
+		frame.Cells[i].Fg, frame.Cells[i].Bg = legend[number].fg, legend[number].bg
+	}
+
+	err = r.readRows(&frame, func(i int, field string) (err error) {
+		frame.Cells[i].Depth, err = strconv.ParseFloat(field, 64)
+		return err
+	})
+	return frame, err
+}
+
+// Reads a golden file a line at a time, counting lines for errors
+type goldenReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *goldenReader) next() (string, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("line %d: golden frame ends too soon", r.line+1)
+	}
+	r.line += 1
+	return r.scanner.Text(), nil
+}
+
+func (r *goldenReader) expect(heading string) error {
+	line, err := r.next()
+	if err != nil {
+		return err
+	}
+	if line != heading {
+		return r.errorf("expected %q, got %q", heading, line)
+	}
+	return nil
+}
+
+// Reads a line of space separated fields for each row of the frame
+func (r *goldenReader) readRows(frame *GoldenFrame, cell func(i int, field string) error) error {
+	for y := 0; y < frame.Height; y++ {
+		line, err := r.next()
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(line)
+		if len(fields) != frame.Width {
+			return r.errorf("expected %d cells, got %d", frame.Width, len(fields))
+		}
+		for x, field := range fields {
+			if err := cell(x+y*frame.Width, field); err != nil {
+				return r.errorf("%v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *goldenReader) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("line %d: %s", r.line, fmt.Sprintf(format, a...))
+}
+
+// Describes every cell that differs between two frames. Empty when they match.
+func Diff(expected, actual *GoldenFrame) string {
+	if expected.Width != actual.Width || expected.Height != actual.Height {
+		return fmt.Sprintf("size is %dx%d, expected %dx%d", actual.Width, actual.Height, expected.Width, expected.Height)
+	}
+
+	var report strings.Builder
+	var changes strings.Builder
+	count := 0
+	for y := 0; y < expected.Height; y++ {
+		for x := 0; x < expected.Width; x++ {
+			want, got := expected.At(x, y), actual.At(x, y)
+			if want == got {
+				changes.WriteByte('.')
+				continue
+			}
+
+			changes.WriteByte('X')
+			count += 1
+			if count <= maxReportedCells {
+				fmt.Fprintf(&report, "  cell %d,%d:\n    expected %v\n    got      %v\n", x, y, want, got)
+			}
+		}
+		changes.WriteByte('\n')
+	}
+
+	if count == 0 {
+		return ""
+	}
+	if count > maxReportedCells {
+		fmt.Fprintf(&report, "  ...and %d more\n", count-maxReportedCells)
+	}
+	return fmt.Sprintf("%d of %d cells differ:\n%s\nChanged cells are marked with X:\n%s", count, len(expected.Cells), report.String(), changes.String())
+}
+
+// Compares what's drawn on a canvas with testdata/<name>.golden, or writes the golden
+// file instead when update is set. Tests usually set it with an -update flag:
+//
+//	var update = flag.Bool("update", false, "rewrite golden files with what's drawn now")
+func AssertGolden(t testing.TB, name string, c *Canvas, update bool) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	actual := NewGoldenFrame(c)
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(actual.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file, run with -update to create it: %v", err)
+	}
+	expected, err := ParseGoldenFrame(string(data))
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", path, err)
+	}
+
+	if diff := Diff(&expected, &actual); diff != "" {
+		t.Errorf("Canvas doesn't match %s, run with -update if this is expected\n%s", path, diff)
+	}
+}
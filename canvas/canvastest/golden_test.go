@@ -0,0 +1,69 @@
+package canvastest
+
+import (
+	"strings"
+	"testing"
+	. "tri/canvas"
+)
+
+func TestGoldenFrameRoundTrip(t *testing.T) {
+	canvas := NewCanvas(4, 2)
+	canvas.Clear()
+	canvas.DrawText(0, 0, "a\"▀")
+	canvas.Set(3, 1, Cell{Fg: 0xff123456, Bg: 0xffabcdef, Depth: 0.12345, Sprite: ' '})
+
+	frame := NewGoldenFrame(&canvas)
+	parsed, err := ParseGoldenFrame(frame.String())
+	if err != nil {
+		t.Fatalf("Failed to parse frame: %v", err)
+	}
+	if diff := Diff(&frame, &parsed); diff != "" {
+		t.Errorf("Frame changed after parsing:\n%s", diff)
+	}
+	if cell := parsed.At(3, 1); cell.Depth != 0.123 {
+		t.Errorf("Depth wasn't quantised: %v", cell.Depth)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	canvas := NewCanvas(3, 2)
+	canvas.Clear()
+	expected := NewGoldenFrame(&canvas)
+
+	canvas.Set(1, 1, Cell{Bg: 0xffff0000, Sprite: ' '})
+	actual := NewGoldenFrame(&canvas)
+
+	diff := Diff(&expected, &actual)
+	if !strings.Contains(diff, "1 of 6 cells differ") || !strings.Contains(diff, "cell 1,1") {
+		t.Errorf("Diff doesn't describe the changed cell:\n%s", diff)
+	}
+	if !strings.Contains(diff, "...\n.X.\n") {
+		t.Errorf("Diff doesn't map the changed cell:\n%s", diff)
+	}
+}
+
+func TestGoldenFrameString(t *testing.T) {
+	canvas := NewCanvas(3, 2)
+	canvas.Clear()
+	canvas.DrawText(0, 0, "ab")
+	canvas.Set(2, 1, Cell{Fg: 0xffffffff, Bg: 0xff2222cc, Depth: 0.5, Sprite: '▀'})
+
+	frame := NewGoldenFrame(&canvas)
+	expected := `size 3 2
+sprites
+"ab "
+"  ▀"
+colours
+0 0 0
+0 0 1
+legend
+0 ffffffff 00000000
+1 ffffffff ff2222cc
+depths
+1000000.000 1000000.000 1000000.000
+1000000.000 1000000.000 0.500
+`
+	if text := frame.String(); text != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, text)
+	}
+}
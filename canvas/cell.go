@@ -1,7 +1,6 @@
 package canvas
 
 import (
-	"fmt"
 	. "tri/geom"
 )
 
@@ -12,19 +11,20 @@ type Cell struct {
 	Sprite rune
 }
 
-func (c *Cell) AnsiColor() string {
-	return fmt.Sprintf("\x1b[38;5;%dm\x1b[48;5;%dm", c.Fg.ToAnsi(), c.Bg.ToAnsi())
-}
-
-func (c *Cell) Ansi24BitColor() string {
-	fr := ((c.Fg & 0x00ff0000) >> 16)
-	fg := ((c.Fg & 0x0000ff00) >> 8)
-	fb := ((c.Fg & 0x000000ff) >> 0)
-	br := ((c.Bg & 0x00ff0000) >> 16)
-	bg := ((c.Bg & 0x0000ff00) >> 8)
-	bb := ((c.Bg & 0x000000ff) >> 0)
-
-	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm", fr, fg, fb, br, bg, bb)
+// Reduces a cell to black and white. Half blocks with both halves the same
+// become spaces, since there's no colour to tell them apart.
+func (c Cell) monochrome() Cell {
+	bw := func(color Color) Color {
+		if color.Luminance() >= 0.5 {
+			return 0xffffffff
+		}
+		return 0xff000000
+	}
+	c.Fg, c.Bg = bw(c.Fg), bw(c.Bg)
+	if c.Fg == c.Bg && (c.Sprite == '▀' || c.Sprite == '▄') {
+		c.Sprite = ' '
+	}
+	return c
 }
 
 func (dst Cell) Blend(src Cell) Cell {
@@ -37,3 +37,26 @@ func (dst Cell) Blend(src Cell) Cell {
 
 	return dst
 }
+
+// Checks if two cells would be drawn identically.
+// Blank cells look the same whatever their foreground colour.
+func (c *Cell) looksLike(other *Cell) bool {
+	if c.Sprite == ' ' && other.Sprite == ' ' {
+		return c.Bg == other.Bg
+	}
+	return c.Fg == other.Fg && c.Bg == other.Bg && c.Sprite == other.Sprite
+}
+
+// Swaps an upper half block for a lower one, or vice versa, keeping the same appearance
+func (c Cell) flipHalfBlock() Cell {
+	switch c.Sprite {
+	case '▀':
+		c.Sprite = '▄'
+	case '▄':
+		c.Sprite = '▀'
+	default:
+		return c
+	}
+	c.Fg, c.Bg = c.Bg, c.Fg
+	return c
+}
@@ -0,0 +1,229 @@
+package canvas
+
+import (
+	. "math"
+	. "tri/geom"
+	. "tri/terminal"
+)
+
+// Changes pixels after a frame is drawn, before it's presented.
+// Pixels are in rows of width, and changes don't affect the canvas itself.
+type PostProcess interface {
+	Process(pixels []Cell, width, height int, mode ColorMode)
+	// Whether to process one pixel per cell. Processes that draw characters need it, as a
+	// character can't be split between pixels. In HalfBlockPixels mode each pair of pixels
+	// is blended together, and both are given the processed cell.
+	WholeCells() bool
+}
+
+// Processing applied when presenting. nil for none.
+func (c *Canvas) PostProcess() PostProcess {
+	return c.postProcess
+}
+
+func (c *Canvas) SetPostProcess(process PostProcess) {
+	c.Lock()
+	c.postProcess = process
+	c.Unlock()
+}
+
+// Closest colour a terminal can show in a colour mode
+func QuantizeColor(color Color, mode ColorMode) Color {
+	alpha := color & 0xff000000
+	switch mode {
+	case Ansi256Color:
+		return alpha | ColorFromAnsi(color.ToAnsi())&0x00ffffff
+	case Ansi16Color:
+		return alpha | Ansi16Palette[color.ToAnsi16()]&0x00ffffff
+	case MonoColor:
+		if color.Luminance() >= 0.5 {
+			return alpha | 0xffffff
+		}
+		return alpha
+	}
+	return color
+}
+
+// Roughly how far apart neighbouring colours are in a palette, from 0.0 to 1.0
+func paletteSpread(mode ColorMode) float64 {
+	switch mode {
+	case Ansi256Color:
+		return 0.2
+	case Ansi16Color:
+		return 0.4
+	case MonoColor:
+		return 1.0
+	}
+	return 0
+}
+
+// Only plain pixels are dithered. Text keeps its colours.
+func isDitherable(cell *Cell) bool {
+	return cell.Sprite == ' '
+}
+
+func colorToVector3(color Color) Vector3 {
+	return Vector3FromColor(uint32(color))
+}
+
+func vector3ToColor(v Vector3, alpha Color) Color {
+	return alpha&0xff000000 | v.ToColor()&0x00ffffff
+}
+
+// Ordered dithering with a Bayer matrix. Patterns stay still when the scene
+// moves, which suits animation better than error diffusion.
+type BayerDither struct {
+	// Width and height of the matrix: 2, 4 or 8
+	Size      int
+	threshold [][]float64
+}
+
+func NewBayerDither(size int) *BayerDither {
+	// Build the matrix by repeatedly tiling the previous one
+	matrix := [][]int{{0}}
+	for n := 1; n < size; n *= 2 {
+		next := make([][]int, n*2)
+		for y := range next {
+			next[y] = make([]int, n*2)
+			for x := range next[y] {
+				quadrant := [2][2]int{{0, 2}, {3, 1}}[y/n][x/n]
+				next[y][x] = 4*matrix[y%n][x%n] + quadrant
+			}
+		}
+		matrix = next
+	}
+
+	// Thresholds from -0.5 to +0.5
+	count := float64(len(matrix) * len(matrix))
+	threshold := make([][]float64, len(matrix))
+	for y, row := range matrix {
+		threshold[y] = make([]float64, len(row))
+		for x, value := range row {
+			threshold[y][x] = (float64(value)+0.5)/count - 0.5
+		}
+	}
+
+	return &BayerDither{Size: len(matrix), threshold: threshold}
+}
+
+func (d *BayerDither) WholeCells() bool {
+	return false
+}
+
+func (d *BayerDither) Process(pixels []Cell, width, height int, mode ColorMode) {
+	spread := paletteSpread(mode)
+	if spread == 0 {
+		return
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pixel := &pixels[x+y*width]
+			if !isDitherable(pixel) {
+				continue
+			}
+			offset := d.threshold[y%d.Size][x%d.Size] * spread
+			color := colorToVector3(pixel.Bg).Add(Vector3{offset, offset, offset})
+			pixel.Bg = QuantizeColor(vector3ToColor(color, pixel.Bg), mode)
+		}
+	}
+}
+
+// Where a pixel's quantisation error is pushed, and how much of it
+type diffusion struct {
+	X, Y   int
+	Weight float64
+}
+
+// Error diffusion dithering, which spreads the difference between a pixel
+// and the closest colour in the palette over the pixels not yet drawn
+type ErrorDiffusionDither struct {
+	kernel []diffusion
+}
+
+func NewFloydSteinbergDither() *ErrorDiffusionDither {
+	return &ErrorDiffusionDither{kernel: []diffusion{
+		{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16},
+	}}
+}
+
+// Only diffuses 3/4 of the error, so it keeps more contrast than Floyd–Steinberg
+func NewAtkinsonDither() *ErrorDiffusionDither {
+	return &ErrorDiffusionDither{kernel: []diffusion{
+		{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8}, {-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8}, {0, 2, 1.0 / 8},
+	}}
+}
+
+func (d *ErrorDiffusionDither) WholeCells() bool {
+	return false
+}
+
+func (d *ErrorDiffusionDither) Process(pixels []Cell, width, height int, mode ColorMode) {
+	if mode == TrueColor {
+		return
+	}
+
+	errors := make([]Vector3, len(pixels))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := x + y*width
+			pixel := &pixels[i]
+			if !isDitherable(pixel) {
+				continue
+			}
+
+			wanted := colorToVector3(pixel.Bg).Add(errors[i]).Clamp(0, 1)
+			pixel.Bg = QuantizeColor(vector3ToColor(wanted, pixel.Bg), mode)
+			diff := wanted.Sub(colorToVector3(pixel.Bg))
+
+			for _, k := range d.kernel {
+				nx, ny := x+k.X, y+k.Y
+				if nx < 0 || nx >= width || ny >= height {
+					continue
+				}
+				j := nx + ny*width
+				errors[j] = errors[j].Add(diff.Scale(k.Weight))
+			}
+		}
+	}
+}
+
+// Characters from darkest to lightest, used by AsciiRamp
+const DefaultAsciiRamp = " .:-=+*#%@"
+
+// Draws pixels as characters, picked by how bright they are. Characters are
+// coloured on a black background, or white in monochrome.
+type AsciiRamp struct {
+	Ramp string
+}
+
+func NewAsciiRamp() *AsciiRamp {
+	return &AsciiRamp{Ramp: DefaultAsciiRamp}
+}
+
+func (a *AsciiRamp) WholeCells() bool {
+	return true
+}
+
+func (a *AsciiRamp) Process(pixels []Cell, width, height int, mode ColorMode) {
+	ramp := []rune(a.Ramp)
+	if len(ramp) == 0 {
+		return
+	}
+
+	for i := range pixels {
+		pixel := &pixels[i]
+		if !isDitherable(pixel) {
+			continue
+		}
+
+		brightness := float64(pixel.Bg.Luminance())
+		index := int(Round(brightness * float64(len(ramp)-1)))
+		pixel.Sprite = ramp[index]
+		pixel.Fg = pixel.Bg | 0xff000000
+		if mode == MonoColor {
+			pixel.Fg = 0xffffffff
+		}
+		pixel.Bg = 0xff000000
+	}
+}
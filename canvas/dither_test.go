@@ -0,0 +1,163 @@
+package canvas
+
+import (
+	"math"
+	"testing"
+	. "tri/geom"
+	. "tri/terminal"
+)
+
+// A flat grey image, with a line of text in the middle
+func newGreyPixels(width, height int, grey uint32) []Cell {
+	pixels := make([]Cell, width*height)
+	for i := range pixels {
+		pixels[i] = Cell{Fg: 0xffffffff, Bg: Color(0xff000000 | grey<<16 | grey<<8 | grey), Sprite: ' '}
+	}
+	pixels[width*height/2].Sprite = 'A'
+	return pixels
+}
+
+// Average brightness of the pixels that aren't text
+func averageLuminance(pixels []Cell) float64 {
+	total, count := 0.0, 0
+	for _, pixel := range pixels {
+		if pixel.Sprite == ' ' {
+			total += float64(pixel.Bg.Luminance())
+			count += 1
+		}
+	}
+	return total / float64(count)
+}
+
+func TestBayerDitherMatrix(t *testing.T) {
+	dither := NewBayerDither(4)
+
+	seen := map[float64]bool{}
+	for _, row := range dither.threshold {
+		for _, value := range row {
+			seen[value] = true
+		}
+	}
+	if dither.Size != 4 || len(seen) != 16 {
+		t.Errorf("Expected 16 different thresholds, got %d", len(seen))
+	}
+	// The first row of the classic matrix is 0 8 2 10
+	order := []int{0, 8, 2, 10}
+	for x, value := range dither.threshold[0] {
+		if expected := (float64(order[x])+0.5)/16 - 0.5; value != expected {
+			t.Errorf("Threshold at %d is %v, expected %v", x, value, expected)
+		}
+	}
+}
+
+func TestDitherKeepsBrightness(t *testing.T) {
+	processes := map[string]PostProcess{
+		"bayer":           NewBayerDither(4),
+		"floyd-steinberg": NewFloydSteinbergDither(),
+		"atkinson":        NewAtkinsonDither(),
+	}
+
+	for name, process := range processes {
+		for _, mode := range []ColorMode{MonoColor, Ansi16Color, Ansi256Color} {
+			pixels := newGreyPixels(16, 16, 0x70)
+			expected := averageLuminance(pixels)
+			process.Process(pixels, 16, 16, mode)
+
+			for _, pixel := range pixels {
+				if pixel.Sprite == ' ' && QuantizeColor(pixel.Bg, mode) != pixel.Bg {
+					t.Errorf("%s %v: pixel %08x isn't in the palette", name, mode, uint32(pixel.Bg))
+					break
+				}
+			}
+			if text := pixels[16*16/2]; text.Bg != 0xff707070 {
+				t.Errorf("%s %v: text was dithered", name, mode)
+			}
+			// Atkinson loses some error on purpose
+			if result := averageLuminance(pixels); math.Abs(result-expected) > 0.1 {
+				t.Errorf("%s %v: brightness changed from %v to %v", name, mode, expected, result)
+			}
+		}
+	}
+}
+
+func TestDitherSkipsTrueColor(t *testing.T) {
+	pixels := newGreyPixels(4, 4, 0x70)
+	NewFloydSteinbergDither().Process(pixels, 4, 4, TrueColor)
+	NewBayerDither(2).Process(pixels, 4, 4, TrueColor)
+	if pixels[0].Bg != 0xff707070 {
+		t.Errorf("Colours were changed with truecolor: %08x", uint32(pixels[0].Bg))
+	}
+}
+
+func TestAsciiRamp(t *testing.T) {
+	pixels := []Cell{
+		{Bg: 0xff000000, Sprite: ' '},
+		{Bg: 0xff707070, Sprite: ' '},
+		{Bg: 0xffffffff, Sprite: ' '},
+		{Bg: 0xff808080, Sprite: 'x'},
+	}
+	NewAsciiRamp().Process(pixels, 4, 1, MonoColor)
+
+	sprites := string([]rune{pixels[0].Sprite, pixels[1].Sprite, pixels[2].Sprite, pixels[3].Sprite})
+	if sprites != " =@x" {
+		t.Errorf("Expected \" =@x\", got %q", sprites)
+	}
+	if pixels[1].Fg != 0xffffffff || pixels[1].Bg != 0xff000000 {
+		t.Errorf("Expected white on black, got %08x on %08x", uint32(pixels[1].Fg), uint32(pixels[1].Bg))
+	}
+}
+
+func TestAsciiRampHalfBlocks(t *testing.T) {
+	canvas := NewCanvas(2, 1)
+	canvas.SetPixelMode(HalfBlockPixels)
+	// Black over white, and white over white
+	canvas.Set(0, 0, Cell{Bg: 0xff000000, Sprite: ' '})
+	canvas.Set(0, 1, Cell{Bg: 0xffffffff, Sprite: ' '})
+	canvas.Set(1, 0, Cell{Bg: 0xffffffff, Sprite: ' '})
+	canvas.Set(1, 1, Cell{Bg: 0xffffffff, Sprite: ' '})
+	canvas.SetPostProcess(NewAsciiRamp())
+
+	pixels := canvas.processPixels(TrueColor)
+	first, second := canvas.packCell(pixels, 0, 0), canvas.packCell(pixels, 1, 0)
+
+	// Both halves count towards the character, so half black is halfway up the ramp
+	if first.Sprite != '=' || first.Fg != 0xff7f7f7f {
+		t.Errorf("Expected a grey = for half black, got %q in %08x", first.Sprite, uint32(first.Fg))
+	}
+	if second.Sprite != '@' || second.Fg != 0xffffffff {
+		t.Errorf("Expected a white @, got %q in %08x", second.Sprite, uint32(second.Fg))
+	}
+}
+
+// Records the size of what it's given to process
+type sizeRecorder struct {
+	wholeCells    bool
+	width, height int
+}
+
+func (r *sizeRecorder) WholeCells() bool {
+	return r.wholeCells
+}
+
+func (r *sizeRecorder) Process(pixels []Cell, width, height int, mode ColorMode) {
+	r.width, r.height = width, height
+}
+
+func TestPostProcessWholeCells(t *testing.T) {
+	canvas := NewCanvas(4, 3)
+	canvas.SetPixelMode(HalfBlockPixels)
+
+	for _, wholeCells := range []bool{false, true} {
+		recorder := &sizeRecorder{wholeCells: wholeCells}
+		canvas.SetPostProcess(recorder)
+		canvas.processPixels(TrueColor)
+
+		height := 6
+		if wholeCells {
+			height = 3
+		}
+		if recorder.width != 4 || recorder.height != height {
+			t.Errorf("Whole cells %v: expected 4x%d, got %dx%d", wholeCells, height, recorder.width, recorder.height)
+		}
+	}
+}
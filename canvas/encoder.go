@@ -0,0 +1,244 @@
+package canvas
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	. "tri/terminal"
+	"unicode/utf8"
+)
+
+// Escape sequences Present may use beyond cursor movement and colours
+type PresentOptions struct {
+	// Clear runs of blank cells with ECH (erase characters) instead of writing spaces
+	EraseRuns bool
+	// Write runs of the same character with REP (repeat). Not every terminal
+	// supports it, the Linux console and older xterms don't.
+	RepeatRuns bool
+}
+
+// How much was sent to the terminal for a frame
+type FrameStats struct {
+	Bytes        int
+	Cells        int
+	CursorMoves  int
+	ColorChanges int
+}
+
+// Turns changed cells into as few bytes of escape sequences as possible.
+// Keeps track of where the cursor is, and which colours are set.
+type encoder struct {
+	options PresentOptions
+	mode    ColorMode
+	width   int
+	// If the right edge is the end of the terminal's lines, so they can be erased to the end
+	fullWidth bool
+	buf       bytes.Buffer
+	stats     FrameStats
+	// -1 when unknown, e.g. after writing to the last column
+	x, y int
+	// SGR parameters of the current colours, empty when unknown
+	fg, bg string
+}
+
+func newEncoder(mode ColorMode, width int, fullWidth bool, options PresentOptions) *encoder {
+	return &encoder{
+		options:   options,
+		mode:      mode,
+		width:     width,
+		fullWidth: fullWidth,
+		x:         -1,
+		y:         -1,
+	}
+}
+
+// SGR parameters for a cell's foreground, or empty if it doesn't have one
+func (e *encoder) fgParams(cell *Cell) string {
+	if cell.Sprite == ' ' {
+		// Nothing is drawn in the foreground, so any colour will do
+		return ""
+	}
+
+	switch e.mode {
+	case TrueColor:
+		return fmt.Sprintf("38;2;%d;%d;%d", (cell.Fg>>16)&0xff, (cell.Fg>>8)&0xff, cell.Fg&0xff)
+	case Ansi256Color:
+		return "38;5;" + strconv.Itoa(int(cell.Fg.ToAnsi()))
+	case Ansi16Color:
+		index := int(cell.Fg.ToAnsi16())
+		if index >= 8 {
+			return strconv.Itoa(90 + index - 8)
+		}
+		return strconv.Itoa(30 + index)
+	}
+	// Monochrome cells only differ by reverse video, which is set with the background
+	return ""
+}
+
+// SGR parameters for a cell's background
+func (e *encoder) bgParams(cell *Cell) string {
+	switch e.mode {
+	case TrueColor:
+		return fmt.Sprintf("48;2;%d;%d;%d", (cell.Bg>>16)&0xff, (cell.Bg>>8)&0xff, cell.Bg&0xff)
+	case Ansi256Color:
+		return "48;5;" + strconv.Itoa(int(cell.Bg.ToAnsi()))
+	case Ansi16Color:
+		index := int(cell.Bg.ToAnsi16())
+		if index >= 8 {
+			return strconv.Itoa(100 + index - 8)
+		}
+		return strconv.Itoa(40 + index)
+	}
+	if cell.Bg.Luminance() >= 0.5 {
+		return "7"
+	}
+	return "27"
+}
+
+// Checks if a cell can be drawn without changing colour
+func (e *encoder) hasColors(cell *Cell) bool {
+	fg := e.fgParams(cell)
+	return (fg == "" || fg == e.fg) && e.bgParams(cell) == e.bg
+}
+
+// Sets the colours for a cell, sending only the ones that changed
+func (e *encoder) setColors(cell *Cell) {
+	params := ""
+	if fg := e.fgParams(cell); fg != "" && fg != e.fg {
+		e.fg = fg
+		params = fg
+	}
+	if bg := e.bgParams(cell); bg != e.bg {
+		e.bg = bg
+		if params != "" {
+			params += ";"
+		}
+		params += bg
+	}
+
+	if params != "" {
+		e.buf.WriteString("\x1b[" + params + "m")
+		e.stats.ColorChanges += 1
+	}
+}
+
+// Cursor movement relative to the current position, or empty if it's unknown
+func (e *encoder) relativeMove(x, y int) string {
+	if e.x < 0 || e.y < 0 {
+		return ""
+	}
+
+	vertical := ""
+	dy := y - e.y
+	switch {
+	case dy == 1:
+		vertical = "\x1b[B"
+	case dy > 1:
+		vertical = "\x1b[" + strconv.Itoa(dy) + "B"
+	case dy == -1:
+		vertical = "\x1b[A"
+	case dy < -1:
+		vertical = "\x1b[" + strconv.Itoa(-dy) + "A"
+	}
+
+	horizontal := ""
+	dx := x - e.x
+	switch {
+	case dx == 1:
+		horizontal = "\x1b[C"
+	case dx > 1:
+		horizontal = "\x1b[" + strconv.Itoa(dx) + "C"
+	case dx < 0 && dx >= -3:
+		horizontal = strings.Repeat("\b", -dx)
+	case dx < 0:
+		horizontal = "\x1b[" + strconv.Itoa(-dx) + "D"
+	}
+
+	return vertical + horizontal
+}
+
+// Moves the cursor using whichever escape sequence is shortest
+func (e *encoder) moveTo(x, y int) {
+	if x == e.x && y == e.y {
+		return
+	}
+
+	// Absolute position, leaving out parameters that are 1
+	best := "\x1b[" + strconv.Itoa(y+1) + ";" + strconv.Itoa(x+1) + "H"
+	if x == 0 {
+		best = "\x1b[" + strconv.Itoa(y+1) + "H"
+	}
+
+	candidates := []string{}
+	if move := e.relativeMove(x, y); move != "" {
+		candidates = append(candidates, move)
+	}
+	if e.y >= 0 && y >= e.y && y-e.y <= 2 {
+		// Back to the start of the line, down, then across
+		newlines := "\r"
+		if y > e.y {
+			newlines = strings.Repeat("\r\n", y-e.y)
+		}
+		start := encoder{x: 0, y: y}
+		candidates = append(candidates, newlines+start.relativeMove(x, y))
+	}
+	if y == e.y {
+		candidates = append(candidates, "\x1b["+strconv.Itoa(x+1)+"G")
+	}
+
+	for _, candidate := range candidates {
+		if len(candidate) < len(best) {
+			best = candidate
+		}
+	}
+
+	e.buf.WriteString(best)
+	e.x, e.y = x, y
+	e.stats.CursorMoves += 1
+}
+
+// Draws a run of identical cells, starting at a position
+func (e *encoder) draw(x, y int, cell *Cell, count int) {
+	e.moveTo(x, y)
+	e.setColors(cell)
+	e.stats.Cells += count
+
+	sprite := string(cell.Sprite)
+
+	// Erasing fills with the background colour without moving the cursor,
+	// so it's only worth it when skipping ahead afterwards is cheap too
+	if e.options.EraseRuns && cell.Sprite == ' ' {
+		if e.fullWidth && x+count == e.width && count > 3 {
+			e.buf.WriteString("\x1b[K")
+			return
+		}
+		erase := "\x1b[" + strconv.Itoa(count) + "X"
+		skip := "\x1b[" + strconv.Itoa(count) + "C"
+		if len(erase)+len(skip) < count {
+			e.buf.WriteString(erase)
+			return
+		}
+	}
+
+	e.buf.WriteString(sprite)
+	if count > 1 {
+		repeat := "\x1b[" + strconv.Itoa(count-1) + "b"
+		if e.options.RepeatRuns && len(repeat) < (count-1)*utf8.RuneLen(cell.Sprite) {
+			e.buf.WriteString(repeat)
+		} else {
+			e.buf.WriteString(strings.Repeat(sprite, count-1))
+		}
+	}
+
+	e.x += count
+	if e.x >= e.width {
+		// The cursor is waiting to wrap, and terminals disagree about where that is
+		e.x, e.y = -1, -1
+	}
+}
+
+func (e *encoder) Bytes() []byte {
+	e.stats.Bytes = e.buf.Len()
+	return e.buf.Bytes()
+}
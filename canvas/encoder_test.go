@@ -0,0 +1,357 @@
+package canvas
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+	. "tri/geom"
+	. "tri/terminal"
+	"unicode/utf8"
+)
+
+// A cell on the screen of fakeTerminal, with its colours as SGR parameters
+type screenCell struct {
+	Sprite rune
+	Fg, Bg string
+}
+
+// Just enough of a terminal to follow the escape sequences the encoder writes
+type fakeTerminal struct {
+	width, height int
+	cells         []screenCell
+	x, y          int
+	// Set after writing to the last column, until the next character wraps
+	pending bool
+	fg, bg  string
+}
+
+func newFakeTerminal(width, height int) *fakeTerminal {
+	return &fakeTerminal{
+		width:  width,
+		height: height,
+		cells:  make([]screenCell, width*height),
+	}
+}
+
+func (f *fakeTerminal) at(x, y int) *screenCell {
+	return &f.cells[x+y*f.width]
+}
+
+func (f *fakeTerminal) clamp() {
+	f.x = clampInt(f.x, 0, f.width-1)
+	f.y = clampInt(f.y, 0, f.height-1)
+	f.pending = false
+}
+
+func clampInt(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+func (f *fakeTerminal) erase(from, to int) {
+	for x := from; x < to && x < f.width; x++ {
+		*f.at(x, f.y) = screenCell{Sprite: ' ', Bg: f.bg}
+	}
+}
+
+func (f *fakeTerminal) print(r rune) {
+	if f.pending {
+		f.x, f.y, f.pending = 0, f.y+1, false
+	}
+	*f.at(f.x, f.y) = screenCell{Sprite: r, Fg: f.fg, Bg: f.bg}
+	if f.x == f.width-1 {
+		f.pending = true
+	} else {
+		f.x += 1
+	}
+}
+
+// Splits SGR parameters back into the groups the encoder built them from
+func (f *fakeTerminal) sgr(params []string) {
+	for i := 0; i < len(params); i++ {
+		code, _ := strconv.Atoi(params[i])
+		size := 1
+		if code == 38 || code == 48 {
+			size = 3
+			if params[i+1] == "2" {
+				size = 5
+			}
+		}
+		group := strings.Join(params[i:i+size], ";")
+		if (code >= 30 && code < 40) || (code >= 90 && code < 98) {
+			f.fg = group
+		} else {
+			f.bg = group
+		}
+		i += size - 1
+	}
+}
+
+func (f *fakeTerminal) Write(t *testing.T, data []byte) {
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+
+		switch r {
+		case '\r':
+			f.x, f.pending = 0, false
+			continue
+		case '\n':
+			f.y += 1
+			f.clamp()
+			continue
+		case '\b':
+			f.x -= 1
+			f.clamp()
+			continue
+		case 0x1b:
+		default:
+			f.print(r)
+			continue
+		}
+
+		if len(data) == 0 || data[0] != '[' {
+			t.Fatalf("Unexpected escape sequence %q", data)
+		}
+		end := 1
+		for data[end] < 0x40 {
+			end++
+		}
+		params, final := string(data[1:end]), data[end]
+		data = data[end+1:]
+
+		n, err := strconv.Atoi(params)
+		if err != nil {
+			n = 1
+		}
+		switch final {
+		case 'A':
+			f.y -= n
+		case 'B':
+			f.y += n
+		case 'C':
+			f.x += n
+		case 'D':
+			f.x -= n
+		case 'G':
+			f.x = n - 1
+		case 'H':
+			parts := strings.Split(params, ";")
+			f.y, _ = strconv.Atoi(parts[0])
+			f.x = 1
+			if len(parts) > 1 {
+				f.x, _ = strconv.Atoi(parts[1])
+			}
+			f.x, f.y = f.x-1, f.y-1
+		case 'X':
+			f.erase(f.x, f.x+n)
+		case 'K':
+			f.erase(f.x, f.width)
+		case 'b':
+			last := *f.at(f.x-1, f.y)
+			if f.pending {
+				last = *f.at(f.x, f.y)
+			}
+			for i := 0; i < n; i++ {
+				f.print(last.Sprite)
+			}
+			continue
+		case 'm':
+			f.sgr(strings.Split(params, ";"))
+			continue
+		default:
+			t.Fatalf("Unexpected escape sequence %q", "\x1b["+params+string(final))
+		}
+		f.clamp()
+	}
+}
+
+// Fills a canvas with a few colours, in runs, so there's something for the encoder to coalesce
+func drawRandomCells(c *Canvas, rng *rand.Rand) {
+	colors := []Color{0xff000000, 0xffff0000, 0xff00ff00, 0xff2040ff, 0xffffffff}
+	sprites := []rune{' ', ' ', ' ', 'a', 'é'}
+	cell := Cell{Sprite: ' '}
+	for y := 0; y < c.PixelHeight(); y++ {
+		for x := 0; x < c.PixelWidth(); x++ {
+			if rng.Intn(3) == 0 {
+				cell = Cell{
+					Sprite: sprites[rng.Intn(len(sprites))],
+					Fg:     colors[rng.Intn(len(colors))],
+					Bg:     colors[rng.Intn(len(colors))],
+				}
+			}
+			if c.mode == HalfBlockPixels {
+				// Text only shows when both pixels in the cell are text
+				cell.Sprite = ' '
+			}
+			c.Set(x, y, cell)
+		}
+	}
+}
+
+func TestPresentMatchesCanvas(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, mode := range []ColorMode{TrueColor, Ansi256Color, Ansi16Color, MonoColor} {
+		for _, pixels := range []PixelMode{CellPixels, HalfBlockPixels} {
+			for _, options := range []PresentOptions{{}, {EraseRuns: true}, {EraseRuns: true, RepeatRuns: true}} {
+				// Narrower than the terminal, so erasing to the end of the line isn't allowed
+				for _, termWidth := range []int{23, 30} {
+					c := NewCanvas(23, 7)
+					c.SetPixelMode(pixels)
+					c.SetPresentOptions(options)
+					term := newFakeTerminal(termWidth, c.Height)
+					enc := newEncoder(mode, c.Width, false, options)
+
+					for frame := 0; frame < 6; frame++ {
+						drawRandomCells(&c, rng)
+						e := c.encodeFrame(mode, c.Width, c.Height, termWidth == c.Width)
+						term.Write(t, e.Bytes())
+
+						for y := 0; y < c.Height; y++ {
+							for x := 0; x < c.Width; x++ {
+								front := c.GetFront(x, y)
+								expected := c.CellAt(x, y)
+								if mode == MonoColor {
+									expected = expected.monochrome()
+								}
+								flipped := front.flipHalfBlock()
+								if !front.looksLike(&expected) && !flipped.looksLike(&expected) {
+									t.Fatalf("%v %v %+v: front buffer at %d,%d is %+v, expected %+v", mode, pixels, options, x, y, *front, expected)
+								}
+
+								got := *term.at(x, y)
+								want := screenCell{Sprite: front.Sprite, Fg: enc.fgParams(front), Bg: enc.bgParams(front)}
+								if want.Fg == "" {
+									got.Fg = ""
+								}
+								if got != want {
+									t.Fatalf("%v %v %+v frame %d: screen at %d,%d is %+v, expected %+v", mode, pixels, options, frame, x, y, got, want)
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPresentUnchangedFrame(t *testing.T) {
+	c := NewCanvas(10, 4)
+	drawRandomCells(&c, rand.New(rand.NewSource(2)))
+	c.encodeFrame(TrueColor, c.Width, c.Height, true)
+
+	e := c.encodeFrame(TrueColor, c.Width, c.Height, true)
+	if len(e.Bytes()) != 0 {
+		t.Errorf("Expected nothing to be sent for an unchanged frame, got %q", e.Bytes())
+	}
+	if e.stats != (FrameStats{}) {
+		t.Errorf("Expected empty stats, got %+v", e.stats)
+	}
+
+	c.Invalidate()
+	e = c.encodeFrame(TrueColor, c.Width, c.Height, true)
+	if e.stats.Cells != c.Width*c.Height {
+		t.Errorf("Expected every cell to be drawn after invalidating, got %d", e.stats.Cells)
+	}
+}
+
+func TestPresentStats(t *testing.T) {
+	c := NewCanvas(10, 2)
+	c.ClearWithCell(Cell{Sprite: ' ', Bg: 0xff000000})
+	c.encodeFrame(TrueColor, c.Width, c.Height, true)
+
+	c.Set(2, 0, Cell{Sprite: 'a', Fg: 0xffffffff, Bg: 0xff000000})
+	c.Set(3, 0, Cell{Sprite: 'a', Fg: 0xffffffff, Bg: 0xff000000})
+	c.Set(4, 1, Cell{Sprite: ' ', Bg: 0xffff0000})
+	e := c.encodeFrame(TrueColor, c.Width, c.Height, true)
+
+	expected := "\x1b[1;3H\x1b[38;2;255;255;255;48;2;0;0;0maa\x1b[B\x1b[48;2;255;0;0m "
+	if string(e.Bytes()) != expected {
+		t.Errorf("Expected %q, got %q", expected, e.Bytes())
+	}
+	stats := FrameStats{Bytes: len(expected), Cells: 3, CursorMoves: 2, ColorChanges: 2}
+	if e.stats != stats {
+		t.Errorf("Expected %+v, got %+v", stats, e.stats)
+	}
+}
+
+func TestEncoderMoveTo(t *testing.T) {
+	cases := []struct {
+		fromX, fromY, toX, toY int
+		expected               string
+	}{
+		{-1, -1, 0, 0, "\x1b[1H"},
+		{-1, -1, 4, 2, "\x1b[3;5H"},
+		{3, 2, 4, 2, "\x1b[C"},
+		{3, 2, 3, 3, "\x1b[B"},
+		{9, 2, 7, 2, "\b\b"},
+		{30, 2, 0, 3, "\r\n"},
+		{30, 2, 1, 2, "\r\x1b[C"},
+		{3, 5, 3, 2, "\x1b[3A"},
+		{120, 2, 12, 2, "\x1b[13G"},
+	}
+
+	for _, tc := range cases {
+		e := newEncoder(TrueColor, 80, true, PresentOptions{})
+		e.x, e.y = tc.fromX, tc.fromY
+		e.moveTo(tc.toX, tc.toY)
+		if got := string(e.Bytes()); got != tc.expected {
+			t.Errorf("Moving from %d,%d to %d,%d: expected %q, got %q", tc.fromX, tc.fromY, tc.toX, tc.toY, tc.expected, got)
+		}
+	}
+}
+
+func TestEncoderSetColors(t *testing.T) {
+	e := newEncoder(Ansi16Color, 80, true, PresentOptions{})
+	red := Cell{Sprite: 'x', Fg: 0xffff5555, Bg: 0xff000000}
+	blank := Cell{Sprite: ' ', Fg: 0xff00ff00, Bg: 0xff000000}
+
+	e.setColors(&red)
+	e.setColors(&blank)
+	e.setColors(&Cell{Sprite: 'y', Fg: 0xffff5555, Bg: 0xffffffff})
+
+	expected := "\x1b[91;40m\x1b[107m"
+	if got := string(e.Bytes()); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if e.stats.ColorChanges != 2 {
+		t.Errorf("Expected 2 colour changes, got %d", e.stats.ColorChanges)
+	}
+}
+
+func TestEncoderRuns(t *testing.T) {
+	blank := Cell{Sprite: ' ', Bg: 0xff000000}
+	letter := Cell{Sprite: 'a', Fg: 0xffffffff, Bg: 0xff000000}
+	cases := []struct {
+		name    string
+		options PresentOptions
+		cell    Cell
+		x       int
+		count   int
+		suffix  string
+	}{
+		{"short blanks", PresentOptions{EraseRuns: true}, blank, 0, 3, "   "},
+		{"blanks", PresentOptions{EraseRuns: true}, blank, 0, 20, "\x1b[20X"},
+		{"blanks to the end", PresentOptions{EraseRuns: true}, blank, 60, 20, "\x1b[K"},
+		{"blanks without erasing", PresentOptions{}, blank, 0, 8, "        "},
+		{"repeated letters", PresentOptions{RepeatRuns: true}, letter, 0, 20, "a\x1b[19b"},
+		{"letters", PresentOptions{}, letter, 0, 6, "aaaaaa"},
+	}
+
+	for _, tc := range cases {
+		e := newEncoder(TrueColor, 80, true, tc.options)
+		e.x, e.y, e.fg, e.bg = tc.x, 0, e.fgParams(&tc.cell), e.bgParams(&tc.cell)
+		e.draw(tc.x, 0, &tc.cell, tc.count)
+		if got := string(e.Bytes()); got != tc.suffix {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.suffix, got)
+		}
+	}
+}
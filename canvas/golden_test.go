@@ -0,0 +1,52 @@
+package canvas_test
+
+import (
+	"flag"
+	"testing"
+	. "tri/canvas"
+	"tri/canvas/canvastest"
+	. "tri/geom"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with what's drawn now")
+
+func TestDrawTriangle3Golden(t *testing.T) {
+	canvas := NewCanvas(24, 12)
+	canvas.Clear()
+
+	red := Cell{Fg: 0xffffffff, Bg: 0xffcc2222, Sprite: ' '}
+	blue := Cell{Fg: 0xffffffff, Bg: 0xff2222cc, Sprite: ' '}
+	glass := Cell{Fg: 0xffffffff, Bg: Color(0xff22cc22).WithAlpha(0.5), Sprite: ' '}
+
+	// The blue triangle pokes through the red one where it's nearer
+	canvas.DrawTriangle3(Triangle3{{-0.9, -0.8, 0.5}, {0.6, -0.6, 0.5}, {-0.2, 0.9, 0.5}}, red)
+	canvas.DrawTriangle3(Triangle3{{-0.4, 0.7, 0.2}, {0.9, 0.8, 0.8}, {0.3, -0.9, 0.8}}, blue)
+	canvas.DrawTriangle3(Triangle3{{-1, -1, 0.1}, {0, -1, 0.1}, {-1, 0, 0.1}}, glass)
+
+	canvastest.AssertGolden(t, "triangles", &canvas, *update)
+}
+
+func TestDrawTriangle3HalfBlockGolden(t *testing.T) {
+	canvas := NewCanvas(16, 8)
+	canvas.SetPixelMode(HalfBlockPixels)
+	canvas.Clear()
+
+	canvas.DrawTriangle3(Triangle3{{-0.8, -0.9, 0.5}, {0.9, 0.1, 0.5}, {-0.5, 0.8, 0.5}}, Cell{Bg: 0xffeeaa00, Sprite: ' '})
+
+	canvastest.AssertGolden(t, "triangles_half_block", &canvas, *update)
+}
+
+func TestDrawDeepLineGolden(t *testing.T) {
+	canvas := NewCanvas(20, 10)
+	canvas.Clear()
+
+	white := Cell{Fg: 0xffffffff, Bg: 0xffffffff, Sprite: ' '}
+	green := Cell{Fg: 0xffffffff, Bg: 0xff00aa00, Sprite: ' '}
+
+	// The green line crosses in front of the white one
+	canvas.DrawDeepLine(Line3{{0, 0, 0.9}, {19, 9, 0.1}}, white)
+	canvas.DrawDeepLine(Line3{{0, 9, 0.2}, {19, 0, 0.2}}, green)
+	canvas.DrawDeepLine(Line3{{3, 2, 0.5}, {16, 2, 0.5}}, white)
+
+	canvastest.AssertGolden(t, "deep_lines", &canvas, *update)
+}
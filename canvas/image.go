@@ -0,0 +1,153 @@
+package canvas
+
+import (
+	"image"
+	"image/color"
+	"math/bits"
+	. "tri/geom"
+	"unicode"
+)
+
+// How a canvas is drawn into an image
+type ImageOptions struct {
+	// Size of every cell, in image pixels. When zero every canvas pixel
+	// becomes one image pixel, or cells are 8x16 when drawing glyphs.
+	CellWidth  int
+	CellHeight int
+	// Draw the shapes of text and braille. Without glyphs, braille is blended
+	// into the background and text is left out. Half blocks are always drawn.
+	Glyphs bool
+}
+
+// A tiny 3x5 font for drawing text into images. Each glyph is 15 bits, one row
+// of 3 after another, starting from the top left in the highest bit.
+var imageFont = map[rune]uint16{
+	' ': 0x0000, '!': 0x2482, '"': 0x5a00, '#': 0x5f7d, '$': 0x3c9e, '%': 0x42a1,
+	'&': 0x2aab, '\'': 0x2400, '(': 0x1491, ')': 0x4494, '*': 0x0aa8, '+': 0x05d0,
+	',': 0x0014, '-': 0x01c0, '.': 0x0002, '/': 0x12a4, '0': 0x7b6f, '1': 0x2c97,
+	'2': 0x73e7, '3': 0x72cf, '4': 0x5bc9, '5': 0x79cf, '6': 0x79ef, '7': 0x7292,
+	'8': 0x7bef, '9': 0x7bcf, ':': 0x0410, ';': 0x0414, '<': 0x1511, '=': 0x0e38,
+	'>': 0x4454, '?': 0x7282, '@': 0x7be7, 'A': 0x2bed, 'B': 0x6bae, 'C': 0x3923,
+	'D': 0x6b6e, 'E': 0x79e7, 'F': 0x79e4, 'G': 0x396b, 'H': 0x5bed, 'I': 0x7497,
+	'J': 0x126a, 'K': 0x5bad, 'L': 0x4927, 'M': 0x5fed, 'N': 0x6b6d, 'O': 0x2b6a,
+	'P': 0x6ba4, 'Q': 0x2b73, 'R': 0x6bad, 'S': 0x388e, 'T': 0x7492, 'U': 0x5b6f,
+	'V': 0x5b6a, 'W': 0x5bfd, 'X': 0x5aad, 'Y': 0x5a92, 'Z': 0x72a7, '[': 0x3493,
+	'\\': 0x4889, ']': 0x6496, '^': 0x2a00, '_': 0x0007, '`': 0x4400, '{': 0x1591,
+	'|': 0x2492, '}': 0x44d4, '~': 0x0c40,
+}
+
+// Draws what would be presented to a terminal into an image
+func (c *Canvas) Image(options ImageOptions) *image.RGBA {
+	cellWidth, cellHeight := options.CellWidth, options.CellHeight
+	if cellWidth <= 0 || cellHeight <= 0 {
+		if options.Glyphs {
+			cellWidth, cellHeight = 8, 16
+		} else {
+			cellWidth, cellHeight = 1, c.pixelsPerCell()
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, c.Width*cellWidth, c.Height*cellHeight))
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			cell := c.packCell(c.back, x, y)
+			fg := opaqueRgba(cell.Fg)
+			bg := opaqueRgba(cell.Bg)
+
+			for py := 0; py < cellHeight; py++ {
+				for px := 0; px < cellWidth; px++ {
+					// Sample the middle of each image pixel
+					u := (float64(px) + 0.5) / float64(cellWidth)
+					v := (float64(py) + 0.5) / float64(cellHeight)
+					ink := glyphInk(cell.Sprite, u, v, options.Glyphs)
+					img.SetRGBA(x*cellWidth+px, y*cellHeight+py, mixRgba(bg, fg, ink))
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// How much of the foreground colour covers a point within a cell.
+// U and V go from 0.0 to 1.0, left to right and top to bottom.
+func glyphInk(sprite rune, u, v float64, glyphs bool) float64 {
+	inside := func(ok bool) float64 {
+		if ok {
+			return 1
+		}
+		return 0
+	}
+
+	switch sprite {
+	case ' ':
+		return 0
+	case '█':
+		return 1
+	case '▀':
+		return inside(v < 0.5)
+	case '▄':
+		return inside(v >= 0.5)
+	case '▌':
+		return inside(u < 0.5)
+	case '▐':
+		return inside(u >= 0.5)
+	case '░':
+		return 0.25
+	case '▒':
+		return 0.5
+	case '▓':
+		return 0.75
+	}
+
+	if sprite >= 0x2800 && sprite <= 0x28ff {
+		dots := uint8(sprite - 0x2800)
+		if !glyphs {
+			return float64(bits.OnesCount8(dots)) / 8
+		}
+
+		// Round dots in the middle of a 2x4 grid
+		col, row := int(u*2), int(v*4)
+		if dots&brailleDots[row][col] == 0 {
+			return 0
+		}
+		du := u*2 - float64(col) - 0.5
+		dv := v*4 - float64(row) - 0.5
+		return inside(du*du+dv*dv < 0.35*0.35)
+	}
+
+	if !glyphs {
+		return 0
+	}
+
+	// Text is drawn in a 4x8 grid, leaving room between characters and lines
+	col, row := int(u*4), int(v*8)-1
+	if col > 2 || row < 0 || row > 4 {
+		return 0
+	}
+	glyph, ok := imageFont[unicode.ToUpper(sprite)]
+	if !ok {
+		// Unknown characters are drawn as a solid box
+		return 1
+	}
+	bit := 14 - uint(row*3+col)
+	return float64((glyph >> bit) & 1)
+}
+
+// Terminals can't show transparency, so neither do images
+func opaqueRgba(c Color) color.RGBA {
+	return color.RGBA{uint8(c >> 16), uint8(c >> 8), uint8(c), 0xff}
+}
+
+func mixRgba(a, b color.RGBA, amount float64) color.RGBA {
+	if amount <= 0 {
+		return a
+	}
+	if amount >= 1 {
+		return b
+	}
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a)*(1-amount) + float64(b)*amount + 0.5)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xff}
+}
@@ -0,0 +1,68 @@
+package canvas
+
+import (
+	"image/color"
+	"testing"
+	. "tri/geom"
+)
+
+func TestCanvasImageHalfBlocks(t *testing.T) {
+	canvas := NewCanvas(2, 1)
+	canvas.SetPixelMode(HalfBlockPixels)
+	canvas.Clear()
+	canvas.Set(0, 0, Cell{Bg: 0xffff0000, Sprite: ' '})
+	canvas.Set(0, 1, Cell{Bg: 0xff0000ff, Sprite: ' '})
+
+	img := canvas.Image(ImageOptions{})
+	if img.Bounds().Dx() != 2 || img.Bounds().Dy() != 2 {
+		t.Fatalf("Image is the wrong size: %v", img.Bounds())
+	}
+
+	expected := map[[2]int]color.RGBA{
+		{0, 0}: {0xff, 0x00, 0x00, 0xff},
+		{0, 1}: {0x00, 0x00, 0xff, 0xff},
+		{1, 0}: {0x00, 0x00, 0x00, 0xff},
+	}
+	for pos, c := range expected {
+		if result := img.RGBAAt(pos[0], pos[1]); result != c {
+			t.Errorf("Pixel %v is %v, expected %v", pos, result, c)
+		}
+	}
+}
+
+func TestCanvasImageGlyphs(t *testing.T) {
+	canvas := NewCanvas(2, 1)
+	canvas.ClearWithCell(Cell{Fg: 0xffffffff, Bg: 0xff000000, Depth: 1000000, Sprite: ' '})
+	canvas.DrawText(0, 0, "|")
+	canvas.SetBrailleDot(2, 0, 0, Color(0xffffffff))
+
+	countInk := func(options ImageOptions, cell int) int {
+		img := canvas.Image(options)
+		width := img.Bounds().Dx() / 2
+		count := 0
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			for x := cell * width; x < (cell+1)*width; x++ {
+				if img.RGBAAt(x, y).R > 0 {
+					count++
+				}
+			}
+		}
+		return count
+	}
+
+	if ink := countInk(ImageOptions{Glyphs: true}, 0); ink == 0 || ink == 8*16 {
+		t.Errorf("Text wasn't drawn as a glyph, %d pixels lit", ink)
+	}
+	if ink := countInk(ImageOptions{CellWidth: 8, CellHeight: 16}, 0); ink != 0 {
+		t.Errorf("Text was drawn without glyphs, %d pixels lit", ink)
+	}
+	if ink := countInk(ImageOptions{Glyphs: true}, 1); ink == 0 || ink > 8*4 {
+		t.Errorf("Braille dot is the wrong size, %d pixels lit", ink)
+	}
+
+	// Without glyphs a single dot tints the cell
+	img := canvas.Image(ImageOptions{})
+	if c := img.RGBAAt(1, 0); c.R != 0x20 {
+		t.Errorf("Braille cell should be blended by its dots, got %v", c)
+	}
+}
@@ -0,0 +1,151 @@
+package canvas
+
+import (
+	. "math"
+	. "tri/geom"
+)
+
+// Sub-pixel precision of the rasterizer, in bits. Positions are snapped to a
+// 1/16th pixel grid so edges shared by two triangles are tested identically.
+const subPixelBits = 4
+const subPixels = 1 << subPixelBits
+
+// A corner of a triangle to rasterize
+type Vertex struct {
+	// Screen coordinates, -1.0 to +1.0. Z is used for depth testing.
+	Position Point3
+	// Clip space W, used to interpolate attributes with perspective. 1.0 for none.
+	W float64
+	// Values to interpolate across the triangle, e.g. colour, UVs or normals.
+	// Every vertex must have the same number of them.
+	Attributes []float64
+}
+
+// A pixel covered by a triangle
+type Fragment struct {
+	X, Y  int
+	Depth float64
+	// Interpolated vertex attributes. Only valid until the shader returns.
+	Attributes []float64
+}
+
+// Decides what to draw for a fragment. Returning false leaves the pixel untouched.
+type FragmentShader func(frag *Fragment) (Cell, bool)
+
+type fixedPoint struct {
+	X, Y int64
+}
+
+// Twice the signed area of the triangle a, b, p. Positive when p is inside edge a → b.
+func edgeFunction(a, b, p fixedPoint) int64 {
+	return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+}
+
+// Pixels exactly on an edge belong to a triangle only if it's a top or left edge,
+// so they're never drawn twice, or not at all, by neighbouring triangles.
+func isTopLeftEdge(a, b fixedPoint) bool {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	return (dy == 0 && dx > 0) || dy < 0
+}
+
+func (c *Canvas) toFixedPoint(point Point3) fixedPoint {
+	p := c.Point3ToPoint2(point)
+	return fixedPoint{
+		int64(Round(p.X() * subPixels)),
+		int64(Round(p.Y() * subPixels)),
+	}
+}
+
+// Fills a triangle, calling the shader for every visible pixel inside it.
+// Depth is interpolated linearly in screen space, which is correct for
+// projected Z. Attributes are interpolated with perspective correction.
+func (c *Canvas) RasterizeTriangle(verts [3]Vertex, shader FragmentShader) {
+	p0 := c.toFixedPoint(verts[0].Position)
+	p1 := c.toFixedPoint(verts[1].Position)
+	p2 := c.toFixedPoint(verts[2].Position)
+
+	area := edgeFunction(p0, p1, p2)
+	if area == 0 {
+		return
+	}
+	if area < 0 {
+		// Make the winding consistent so inside is always positive
+		verts[1], verts[2] = verts[2], verts[1]
+		p1, p2 = p2, p1
+		area = -area
+	}
+
+	// Bounding box, in whole pixels, limited to the canvas
+	minX := Min(Min(float64(p0.X), float64(p1.X)), float64(p2.X)) / subPixels
+	maxX := Max(Max(float64(p0.X), float64(p1.X)), float64(p2.X)) / subPixels
+	minY := Min(Min(float64(p0.Y), float64(p1.Y)), float64(p2.Y)) / subPixels
+	maxY := Max(Max(float64(p0.Y), float64(p1.Y)), float64(p2.Y)) / subPixels
+	startX := int(Max(Floor(minX), 0))
+	endX := int(Min(Ceil(maxX), float64(c.PixelWidth()-1)))
+	startY := int(Max(Floor(minY), 0))
+	endY := int(Min(Ceil(maxY), float64(c.PixelHeight()-1)))
+
+	topLeft0 := isTopLeftEdge(p1, p2)
+	topLeft1 := isTopLeftEdge(p2, p0)
+	topLeft2 := isTopLeftEdge(p0, p1)
+
+	// Attributes are divided by W so they can be interpolated linearly in screen space
+	numAttrs := len(verts[0].Attributes)
+	invW := [3]float64{}
+	attrs := [3][]float64{}
+	for i, vert := range verts {
+		w := vert.W
+		if w == 0 {
+			w = 1
+		}
+		invW[i] = 1 / w
+		attrs[i] = make([]float64, numAttrs)
+		for j := range attrs[i] {
+			attrs[i][j] = vert.Attributes[j] * invW[i]
+		}
+	}
+
+	frag := Fragment{Attributes: make([]float64, numAttrs)}
+	fArea := float64(area)
+
+	for y := startY; y <= endY; y++ {
+		for x := startX; x <= endX; x++ {
+			// Sample the middle of the pixel
+			p := fixedPoint{
+				int64(x)*subPixels + subPixels/2,
+				int64(y)*subPixels + subPixels/2,
+			}
+
+			w0 := edgeFunction(p1, p2, p)
+			w1 := edgeFunction(p2, p0, p)
+			w2 := edgeFunction(p0, p1, p)
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+			if (w0 == 0 && !topLeft0) || (w1 == 0 && !topLeft1) || (w2 == 0 && !topLeft2) {
+				continue
+			}
+
+			b0, b1, b2 := float64(w0)/fArea, float64(w1)/fArea, float64(w2)/fArea
+
+			depth := b0*verts[0].Position.Z() + b1*verts[1].Position.Z() + b2*verts[2].Position.Z()
+			if depth >= c.DepthAt(x, y) {
+				continue
+			}
+
+			pw := b0*invW[0] + b1*invW[1] + b2*invW[2]
+			for j := range frag.Attributes {
+				frag.Attributes[j] = (b0*attrs[0][j] + b1*attrs[1][j] + b2*attrs[2][j]) / pw
+			}
+			frag.X, frag.Y = x, y
+			frag.Depth = depth
+
+			cell, ok := shader(&frag)
+			if !ok {
+				continue
+			}
+			cell.Depth = depth
+			c.plot(x, y, cell)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package canvas
+
+import (
+	"math"
+	"testing"
+	. "tri/geom"
+)
+
+func TestRasterizeTriangleSharedEdges(t *testing.T) {
+	canvas := NewCanvas(37, 23)
+	canvas.Clear()
+
+	// A fan of triangles around an off-centre point, covering the whole canvas
+	center := Point3{0.13, -0.27, 0}
+	rim := []Point3{
+		{-1, -1, 0}, {-0.3, -1, 0}, {0.4, -1, 0}, {1, -1, 0},
+		{1, -0.1, 0}, {1, 1, 0}, {0.2, 1, 0}, {-1, 1, 0}, {-1, 0.33, 0},
+	}
+
+	hits := make([]int, canvas.PixelWidth()*canvas.PixelHeight())
+	for i := range rim {
+		// Each triangle is nearer than the last so overlaps would pass the depth test
+		depth := -float64(i)
+		a, b := rim[i], rim[(i+1)%len(rim)]
+		a[2], b[2], center[2] = depth, depth, depth
+
+		canvas.RasterizeTriangle([3]Vertex{
+			{Position: center, W: 1},
+			{Position: a, W: 1},
+			{Position: b, W: 1},
+		}, func(frag *Fragment) (Cell, bool) {
+			hits[frag.X+frag.Y*canvas.PixelWidth()] += 1
+			return Cell{Bg: 0xffffffff, Sprite: ' '}, true
+		})
+	}
+
+	for i, count := range hits {
+		if count != 1 {
+			x, y := canvas.indexToPosition(i)
+			t.Errorf("Pixel %d,%d was drawn %d times", x, y, count)
+		}
+	}
+}
+
+func TestRasterizeTrianglePerspective(t *testing.T) {
+	canvas := NewCanvas(64, 1)
+	canvas.Clear()
+
+	// A strip receding into the distance, with an attribute going from 0 to 1 along it
+	near, far := 1.0, 4.0
+	results := make([]float64, canvas.PixelWidth())
+	shader := func(frag *Fragment) (Cell, bool) {
+		results[frag.X] = frag.Attributes[0]
+		return Cell{Bg: 0xffffffff, Sprite: ' '}, true
+	}
+	left := Vertex{Position: Point3{-1, -1, 0}, W: near, Attributes: []float64{0}}
+	right := Vertex{Position: Point3{1, -1, 0}, W: far, Attributes: []float64{1}}
+	canvas.RasterizeTriangle([3]Vertex{
+		left,
+		right,
+		{Position: Point3{1, 3, 0}, W: far, Attributes: []float64{1}},
+	}, shader)
+	canvas.RasterizeTriangle([3]Vertex{
+		left,
+		{Position: Point3{1, 3, 0}, W: far, Attributes: []float64{1}},
+		{Position: Point3{-1, 3, 0}, W: near, Attributes: []float64{0}},
+	}, shader)
+
+	for x, value := range results {
+		// Screen position to distance along the strip
+		s := (float64(x) + 0.5) / float64(canvas.PixelWidth())
+		expected := (s / far) / ((1-s)/near + s/far)
+		if math.Abs(value-expected) > 0.001 {
+			t.Errorf("Attribute at %d is %v, expected %v", x, value, expected)
+		}
+	}
+}
+
+func TestRasterizeTriangleDepth(t *testing.T) {
+	canvas := NewCanvas(8, 8)
+	canvas.Clear()
+
+	red := Cell{Bg: 0xffff0000, Sprite: ' '}
+	blue := Cell{Bg: 0xff0000ff, Sprite: ' '}
+	canvas.DrawTriangle3(Triangle3{Point3{-1, -1, 0.5}, Point3{1, -1, 0.5}, Point3{-1, 1, 0.5}}, red)
+	canvas.DrawTriangle3(Triangle3{Point3{-1, -1, 0.9}, Point3{1, -1, 0.9}, Point3{-1, 1, 0.9}}, blue)
+
+	if cell := canvas.Get(1, 1); cell.Bg != red.Bg || cell.Depth != 0.5 {
+		t.Errorf("Nearer triangle was overdrawn: %#v", cell)
+	}
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	m "math"
 	"os"
 	"os/signal"
-	"syscall"
+	"sync"
 	"time"
 	. "tri/canvas"
 	. "tri/geom"
@@ -16,134 +18,263 @@ import (
 
 const framerate = 30
 
+// How fast the camera moves and turns while keys are held, in units and radians per second
+const (
+	moveSpeed = 20.0
+	turnSpeed = 0.3 * m.Pi
+)
+
+// How long a key counts as held after being pressed, for terminals that don't report releases
+const keyHold = 100 * time.Millisecond
+
+// Directions moved and turned by each key
+var (
+	moveKeys = map[rune]Vector3{
+		'w': {0, 0, -1}, 's': {0, 0, 1}, 'a': {-1, 0, 0}, 'd': {1, 0, 0}, 'e': {0, -1, 0}, 'q': {0, 1, 0},
+	}
+	turnKeys = map[rune]Vector3{
+		',': {0, 1, 0}, KeyLeft: {0, 1, 0}, '.': {0, -1, 0}, KeyRight: {0, -1, 0},
+		'z': {1, 0, 0}, KeyDown: {1, 0, 0}, 'x': {-1, 0, 0}, KeyUp: {-1, 0, 0},
+	}
+)
+
 func main() {
 	term := NewTerminal()
 	width, height := term.Size()
 	canvas := NewCanvas(width, height)
-	renderer := Renderer{
-		Camera: Camera{
-			Projection: NewMatrix4Perspective(float64(width)/float64(height), 45, 0.1, 1000.0),
-			Transform: Transform{
-				Translation: Vector3{0, -12, 10},
-				Rotation:    Vector3{0.25, 0, 0},
-				Scaling:     Vector3{1, 1, 1},
-			},
-		},
-	}
+	renderer := Renderer{Camera: NewCamera(PerspectiveProjection)}
+
+	// Put the terminal back how it was when main returns or panics, and redraw after Ctrl+Z
+	defer term.Guard(canvas.Invalidate)()
+
+	// Stops everything, when the user quits
+	ctx, quit := context.WithCancel(context.Background())
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
-	signal.Notify(c, syscall.SIGWINCH)
 	go func() {
-		for sig := range c {
-			switch sig {
-			// User pressed Ctrl+C
-			case os.Interrupt:
-				term.MainScreen()
-				term.ShowCursor()
-				term.NormalMode()
-				term.DisableMouse()
-				term.Flush()
-				os.Exit(0)
-
-			// Terminal was resized
-			case syscall.SIGWINCH:
-				term.UpdateSize()
-				width, height = term.Size()
-				canvas.Resize(width, height)
-				renderer.Camera.Projection = NewMatrix4Perspective(float64(width)/float64(height), 45, 0.1, 1000.0)
-			}
-		}
+		// User pressed Ctrl+C
+		<-c
+		quit()
 	}()
 
+	// Held while setting up, so Ctrl+Z doesn't suspend half way through
+	term.Lock()
 	term.AltScreen()
 	term.HideCursor()
 	term.RawMode()
+	term.DetectSynchronizedOutput()
+	// Cells are rarely square, so things are stretched to look the right shape
+	term.DetectCellSize()
+	renderer.Camera.SetViewport(width, height, term.CellAspect())
 	term.EnableMouse()
+	term.EnableFocusEvents()
+
+	// Keys are held down to move. Without the kitty keyboard protocol there's no way to
+	// know when they're let go, so a key press counts until just after the next repeat.
+	keys := NewKeyState(keyHold)
+	if term.DetectKittyKeyboard() {
+		term.EnableKittyKeyboard(KittyKeyState)
+		keys.Timeout = 0
+	}
 	term.Clear()
+	term.Unlock()
 
+	// Where the mouse was last, and the size of the screen it's on. Only used by the input goroutine.
 	mouseX, mouseY := -1.0, -1.0
 
+	// Post processing to cycle through, for terminals with few colours
+	dithers := []PostProcess{nil, NewBayerDither(4), NewFloydSteinbergDither(), NewAtkinsonDither(), NewAsciiRamp()}
+	dither := 0
+
+	// Bytes sent for each frame, shown in the corner. Only used by the main loop.
+	showStats := false
+	stats := FrameStats{}
+
+	// Create a scene
+	cube := NewTriangleMeshCube()
+	cubeNode := NewMeshNode("cube", &cube)
+	cubeNode.SetTranslation(Vector3{0, -10, 0})
+
+	// A smaller cube circling the first one
+	moon := NewTriangleMeshCube()
+	moonNode := NewMeshNode("moon", &moon)
+	moonNode.SetTranslation(Vector3{3, 0, 0})
+	moonNode.SetScaling(Vector3{0.3, 0.3, 0.3})
+	cubeNode.AddChild(moonNode)
+
+	scene := NewScene()
+	cubeHandle := scene.AddNode(cubeNode)
+
+	// Ways of moving the camera, switched between with v. The input goroutine changes
+	// them and the main loop moves the camera, so they're locked. The camera itself is
+	// only touched by the main loop, which draws with it.
+	var cameraMux sync.Mutex
+	fly := NewFlyController(Vector3{0, -12, 10}, 0, 0.25)
+	orbit := NewOrbitController(Vector3{0, -10, 0}, 12)
+	orbit.Rotate(0, 0.4)
+	follow := NewFollowController(Vector3{0, -1.5, 5}, 3)
+	controllers := []CameraController{fly, orbit, follow}
+	controller := 0
+
+	// Turns the camera, by yaw left and pitch down
+	turn := func(yaw, pitch float64) {
+		switch controllers[controller] {
+		case fly:
+			fly.Turn(yaw, pitch)
+		case orbit:
+			orbit.Rotate(yaw, pitch)
+		}
+	}
+	// Moves the camera, or what it's orbiting, relative to the way it's looking
+	move := func(direction Vector3) {
+		switch controllers[controller] {
+		case fly:
+			fly.Move(direction)
+		case orbit:
+			orbit.Pan(direction)
+		}
+	}
+
+	// Changes that have to wait for the main loop, which owns the terminal
+	requests := make(chan func(), 16)
+	request := func(change func()) {
+		select {
+		case requests <- change:
+		case <-ctx.Done():
+		}
+	}
+
 	// User input events
 	go func() {
-		for {
-			event := term.NextEvent()
+		for event := range term.Events(ctx) {
 			switch event.EventType {
 			case KeyEvent:
-				velocity := 1.5
+				// Keys held with Ctrl or Alt are shortcuts, not movement
+				if event.Modifiers&^ModShift == 0 || event.KeyAction == KeyRelease {
+					keys.Update(event)
+				}
+				if event.KeyAction != KeyPress {
+					break
+				}
 				switch event.Key {
-				case 'c':
-					term.Clear()
-				case 'w':
-					renderer.Camera.Translate(0, 0, -velocity)
-				case 's':
-					renderer.Camera.Translate(0, 0, velocity)
-				case 'a':
-					renderer.Camera.Translate(-velocity, 0, 0)
-				case 'd':
-					renderer.Camera.Translate(velocity, 0, 0)
-				case 'e':
-					renderer.Camera.Translate(0, -velocity, 0)
-				case 'q':
-					renderer.Camera.Translate(0, velocity, 0)
-				case ',':
-					renderer.Camera.Transform.Rotation[1] += 0.01 * m.Pi
-				case '.':
-					renderer.Camera.Transform.Rotation[1] -= 0.01 * m.Pi
 				case 'z':
-					renderer.Camera.Transform.Rotation[0] += 0.01 * m.Pi
-				case 'x':
-					renderer.Camera.Transform.Rotation[0] -= 0.01 * m.Pi
-				case '\r', '\n':
-					scaleX := &renderer.Camera.Transform.Scaling[0]
-					if *scaleX == 0.5 {
-						*scaleX = 1.0
+					SuspendKey(event)
+				case 'c':
+					if event.Modifiers&ModCtrl != 0 {
+						// Sent as a key instead of a signal when every key is reported
+						quit()
+					} else {
+						request(func() {
+							term.Lock()
+							term.Clear()
+							term.Unlock()
+							canvas.Invalidate()
+						})
+					}
+				case 'h':
+					request(func() {
+						if canvas.PixelMode() == HalfBlockPixels {
+							canvas.SetPixelMode(CellPixels)
+						} else {
+							canvas.SetPixelMode(HalfBlockPixels)
+						}
+					})
+				case 'g':
+					dither = (dither + 1) % len(dithers)
+					process := dithers[dither]
+					request(func() {
+						canvas.SetPostProcess(process)
+					})
+				case 'i':
+					request(func() {
+						showStats = !showStats
+					})
+				case 'v':
+					cameraMux.Lock()
+					controller = (controller + 1) % len(controllers)
+					cameraMux.Unlock()
+				case 'p':
+					// Perspective, orthographic and isometric
+					request(func() {
+						camera := &renderer.Camera
+						camera.SetProjectionType(camera.ProjectionType%IsometricProjection + 1)
+					})
+				case 'n':
+					if scene.Lighting() == nil {
+						// Moonlight, and a lantern hanging under the cube
+						scene.AddLight(NewAmbientLight(Vector3{0.3, 0.4, 1.0}, 0.1))
+						scene.AddLight(NewDirectionalLight(Vector3{0.5, 1, -0.2}, Vector3{0.6, 0.7, 1.0}, 0.15))
+						scene.AddLight(NewPointLight(Point3{0, -6, 0}, Vector3{1.0, 0.7, 0.3}, 1.5, 30))
 					} else {
-						*scaleX = 0.5
+						scene.SetLights(nil)
 					}
 				}
 
+			case ResizeEvent:
+				width, height = event.Width, event.Height
+				newWidth, newHeight := width, height
+				request(func() {
+					canvas.Resize(newWidth, newHeight)
+					renderer.Camera.SetViewport(newWidth, newHeight, term.CellAspect())
+				})
+
+			case FocusEvent:
+				// Keys let go while the terminal isn't focused are never reported
+				if !event.Focused {
+					keys.Reset()
+				}
+
+			case ErrorEvent:
+				quit()
+
 			case MouseEvent:
+				// Position as a fraction of the screen
+				vx := float64(event.MouseX) / float64(width)
+				vy := float64(event.MouseY) / float64(height)
+
+				cameraMux.Lock()
 				switch event.MouseAction {
-				case MouseMove:
-					x := float64(event.MouseX)
-					y := float64(event.MouseY)
-					vx := x / float64(width)
-					vy := y / float64(height)
-					if mouseX > -1.0 {
-						renderer.Camera.Transform.Rotation[1] += (mouseX - vx) * m.Pi
-					}
-					if mouseY > -1.0 {
-						renderer.Camera.Transform.Rotation[0] -= (mouseY - vy) * m.Pi
+				case MouseDrag:
+					if mouseX > -1.0 && mouseY > -1.0 {
+						if event.MouseButton == MouseLeft {
+							turn((mouseX-vx)*m.Pi, (vy-mouseY)*m.Pi)
+						} else {
+							// Slide sideways and up or down
+							move(Vector3{(mouseX - vx) * 20, (mouseY - vy) * 20, 0})
+						}
 					}
-					mouseX = vx
-					mouseY = vy
+					mouseX, mouseY = vx, vy
 
 				case MouseDown:
-					x := float64(event.MouseX)
-					y := float64(event.MouseY)
-					mouseX = x / float64(width)
-					mouseY = y / float64(height)
+					mouseX, mouseY = vx, vy
+
+				case MouseWheel:
+					// Forwards and backwards, or closer and further when orbiting
+					step := 0.0
+					switch event.MouseButton {
+					case MouseScrollUp:
+						step = -1.5
+					case MouseScrollDown:
+						step = 1.5
+					}
+					if controllers[controller] == orbit {
+						orbit.Zoom(1 + step/10)
+					} else {
+						move(Vector3{0, 0, step})
+					}
 
 				case MouseUp:
 					mouseX, mouseY = -1.0, -1.0
 				}
+				cameraMux.Unlock()
 			}
 		}
 	}()
 
-	// Create a scene
-	cube := NewTriangleMeshCube()
-	cube.Transform = Transform{
-		Translation: Vector3{0, -10, 0},
-		Rotation:    Vector3{0, 0, 0},
-		Scaling:     Vector3{1, 1, 1},
-	}
-
-	scene := NewScene()
-	cubeId := scene.Add(cube)
-
 	chunkSize := 8
+	// One of the chunks bounces, once it's loaded
+	bouncingChunk := make(chan Handle, 1)
 	go func() {
 		for x := -2; x < 2; x++ {
 			for y := -2; y < 2; y++ {
@@ -154,25 +285,57 @@ func main() {
 					Rotation:    Vector3{0, 0, 0},
 					Scaling:     Vector3{1, 5, 1},
 				}
-				scene.Add(terrain)
+				handle := scene.Add(terrain)
+				if x == -1 && y == -1 {
+					bouncingChunk <- handle
+				}
 			}
 		}
 	}()
 
 	// Main loop
 	t := 1.0
-	for {
+	var bouncing Handle
+	for ctx.Err() == nil {
 		dt := 1.0 / float64(framerate)
 
 		f := 0.5 * m.Pi
 
-		cubeMesh := scene.Mesh(cubeId)
-		cubeMesh.Transform.Rotation[0] += f * dt
-		cubeMesh.Transform.Rotation[1] += f * dt
-		if len(scene.Meshes) > 7 {
-			terrainMesh := scene.Mesh(6)
-			terrainMesh.Transform.Translation[1] = 2 * -m.Abs(m.Sin(3*t))
+		var moonPosition Point3
+		scene.Update(cubeHandle, func(node *Node) {
+			node.SetRotation(node.Transform().Rotation.Add(Vector3{f * dt, f * dt, 0}))
+			moonPosition = node.Find("moon").WorldPosition()
+		})
+
+		cameraMux.Lock()
+		for key, direction := range moveKeys {
+			if keys.IsDown(key) {
+				move(direction.Scale(moveSpeed * dt))
+			}
 		}
+		for key, direction := range turnKeys {
+			if keys.IsDown(key) {
+				turn(direction[1]*turnSpeed*dt, direction[0]*turnSpeed*dt)
+			}
+		}
+		follow.Follow(moonPosition.ToVector3(), 0, dt)
+		controllers[controller].Apply(&renderer.Camera)
+		cameraMux.Unlock()
+		select {
+		case bouncing = <-bouncingChunk:
+		default:
+		}
+		for waiting := true; waiting; {
+			select {
+			case change := <-requests:
+				change()
+			default:
+				waiting = false
+			}
+		}
+		scene.Update(bouncing, func(node *Node) {
+			node.Mesh.Transform.Translation[1] = 2 * -m.Abs(m.Sin(3*t))
+		})
 
 		canvas.Lock()
 		canvas.ClearWithCell(Cell{
@@ -181,11 +344,15 @@ func main() {
 			Depth:  1000000,
 			Sprite: ' ',
 		})
-		renderer.RenderDrawable(&canvas, &scene)
-		canvas.Present(&term)
+		renderer.RenderDrawable(&canvas, scene.Snapshot())
+		if showStats {
+			canvas.DrawText(0, 0, fmt.Sprintf(" %d bytes, %d cells, %d moves, %d colours ", stats.Bytes, stats.Cells, stats.CursorMoves, stats.ColorChanges))
+		}
+		stats = canvas.Present(term)
 		canvas.Unlock()
 
 		time.Sleep((1000 / framerate) * time.Millisecond)
 		t += dt
 	}
+
 }
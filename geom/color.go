@@ -24,12 +24,104 @@ func (c Color) ToRgba() (float32, float32, float32, float32) {
 	return r, g, b, a
 }
 
+func (c Color) Alpha() float32 {
+	_, _, _, a := c.ToRgba()
+	return a
+}
+
+// Replace the alpha channel. 0.0 is transparent, 1.0 is opaque.
+func (c Color) WithAlpha(alpha float64) Color {
+	return Color(uint32(c)&0x00ffffff | uint32(alpha*0xff)<<24)
+}
+
+// Levels of each channel in the 256 colour palette's 6x6x6 cube
+var ansiCubeLevels = [6]uint8{0x00, 0x5f, 0x87, 0xaf, 0xd7, 0xff}
+
+// The 16 standard terminal colours, as the Linux console and VGA draw them
+var Ansi16Palette = [16]Color{
+	0xff000000, 0xffaa0000, 0xff00aa00, 0xffaa5500,
+	0xff0000aa, 0xffaa00aa, 0xff00aaaa, 0xffaaaaaa,
+	0xff555555, 0xffff5555, 0xff55ff55, 0xffffff55,
+	0xff5555ff, 0xffff55ff, 0xff55ffff, 0xffffffff,
+}
+
+func (c Color) channels() (int, int, int) {
+	return int((c >> 16) & 0xff), int((c >> 8) & 0xff), int(c & 0xff)
+}
+
+// How different two colours look, weighted for human eyes ("redmean")
+func (c Color) distance(other Color) int {
+	r1, g1, b1 := c.channels()
+	r2, g2, b2 := other.channels()
+	rMean := (r1 + r2) / 2
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return ((512+rMean)*dr*dr)>>8 + 4*dg*dg + ((767-rMean)*db*db)>>8
+}
+
+// Closest colour in the 256 colour palette, from either the colour cube or the greyscale ramp
 func (c Color) ToAnsi() uint16 {
-	//a := ((c & 0xff000000) >> 24) / 51
-	r := ((c & 0x00ff0000) >> 16) / 51
-	g := ((c & 0x0000ff00) >> 8) / 51
-	b := ((c & 0x000000ff) >> 0) / 51
-	return uint16(16 + 36*r + 6*g + b)
+	r, g, b := c.channels()
+
+	cubeIndex := func(v int) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (v - 35) / 40
+	}
+	ri, gi, bi := cubeIndex(r), cubeIndex(g), cubeIndex(b)
+	cube := Color(0xff000000 | uint32(ansiCubeLevels[ri])<<16 | uint32(ansiCubeLevels[gi])<<8 | uint32(ansiCubeLevels[bi]))
+
+	// 24 greys from 0x08 to 0xee
+	greyIndex := ((r+g+b)/3 - 3) / 10
+	if greyIndex < 0 {
+		greyIndex = 0
+	}
+	if greyIndex > 23 {
+		greyIndex = 23
+	}
+	level := uint32(8 + greyIndex*10)
+	grey := Color(0xff000000 | level<<16 | level<<8 | level)
+
+	if c.distance(grey) < c.distance(cube) {
+		return uint16(232 + greyIndex)
+	}
+	return uint16(16 + 36*ri + 6*gi + bi)
+}
+
+// The colour of an entry in the 256 colour palette
+func ColorFromAnsi(index uint16) Color {
+	switch {
+	case index < 16:
+		return Ansi16Palette[index]
+	case index < 232:
+		i := index - 16
+		r, g, b := ansiCubeLevels[i/36], ansiCubeLevels[(i/6)%6], ansiCubeLevels[i%6]
+		return Color(0xff000000 | uint32(r)<<16 | uint32(g)<<8 | uint32(b))
+	case index < 256:
+		level := uint32(8 + (index-232)*10)
+		return Color(0xff000000 | level<<16 | level<<8 | level)
+	}
+	return 0xff000000
+}
+
+// Closest of the 16 standard terminal colours
+func (c Color) ToAnsi16() uint8 {
+	best := 0
+	for i, color := range Ansi16Palette {
+		if c.distance(color) < c.distance(Ansi16Palette[best]) {
+			best = i
+		}
+	}
+	return uint8(best)
+}
+
+// Perceived brightness, from 0.0 to 1.0
+func (c Color) Luminance() float32 {
+	r, g, b := c.ToRgb()
+	return 0.2126*r + 0.7152*g + 0.0722*b
 }
 
 func (dst Color) Blend(src Color) Color {
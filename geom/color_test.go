@@ -0,0 +1,60 @@
+package geom
+
+import "testing"
+
+func TestColorToAnsi(t *testing.T) {
+	tests := []struct {
+		color    Color
+		expected uint16
+	}{
+		{0xff000000, 16},
+		{0xffffffff, 231},
+		{0xffff0000, 196},
+		{0xff5f87af, 67},
+		// Greys between the cube's levels use the greyscale ramp
+		{0xff808080, 244},
+		{0xff121212, 233},
+		{0xffeeeeee, 255},
+		// Not quite grey is closer to the ramp than the cube
+		{0xff303234, 236},
+	}
+
+	for _, test := range tests {
+		if result := test.color.ToAnsi(); result != test.expected {
+			t.Errorf("Expected %08x to be %d, got %d", uint32(test.color), test.expected, result)
+		}
+	}
+}
+
+func TestColorToAnsi16(t *testing.T) {
+	tests := []struct {
+		color    Color
+		expected uint8
+	}{
+		{0xff000000, 0},
+		{0xffffffff, 15},
+		{0xffff4444, 9},
+		{0xff990000, 1},
+		{0xff00a000, 2},
+		{0xffb0b0b0, 7},
+		{0xff4a4a5a, 8},
+		{0xff1010b0, 4},
+	}
+
+	for _, test := range tests {
+		if result := test.color.ToAnsi16(); result != test.expected {
+			t.Errorf("Expected %08x to be %d, got %d", uint32(test.color), test.expected, result)
+		}
+	}
+}
+
+func TestColorFromAnsi(t *testing.T) {
+	for i := uint16(16); i < 256; i++ {
+		if result := ColorFromAnsi(i).ToAnsi(); result != i {
+			t.Errorf("Palette colour %d maps back to %d", i, result)
+		}
+	}
+	if c := ColorFromAnsi(9); c != 0xffff5555 {
+		t.Errorf("Expected bright red, got %08x", uint32(c))
+	}
+}
@@ -19,6 +19,14 @@ func (t Triangle3) Centroid() Point3 {
 	return Point3{x, y, z}
 }
 
+// Normal of the triangle's face. Points towards the side the vertices
+// appear clockwise from, when Y is up.
+func (t Triangle3) Normal() Vector3 {
+	line1 := t[2].ToVector3().Sub(t[0].ToVector3())
+	line2 := t[1].ToVector3().Sub(t[0].ToVector3())
+	return line1.Cross(line2).Normalize()
+}
+
 func (p Plane3) Distance() float64 {
 	return p.Point.ToVector3().Dot(p.Normal)
 }
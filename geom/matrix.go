@@ -35,6 +35,26 @@ func NewMatrix4Perspective(aspect, fov, near, far float64) Matrix4 {
 	}
 }
 
+// Projection without perspective, showing a view height units tall
+func NewMatrix4Orthographic(aspect, height, near, far float64) Matrix4 {
+	h := 2 / height
+	r := 1.0 / (near - far)
+	return Matrix4{
+		h / aspect, 0, 0, 0,
+		0, h, 0, 0,
+		0, 0, 2 * r, (near + far) * r,
+		0, 0, 0, 1,
+	}
+}
+
+// Orthographic projection looking down from a corner, at the same angle to every axis
+func NewMatrix4Isometric(aspect, height, near, far float64) Matrix4 {
+	// Turned 45° around, then tilted down until the view runs along a diagonal
+	yaw := NewMatrix4Rotation(0, -math.Pi/4, 0)
+	pitch := NewMatrix4Rotation(-math.Atan(1/math.Sqrt2), 0, 0)
+	return NewMatrix4Orthographic(aspect, height, near, far).Multiply(pitch).Multiply(yaw)
+}
+
 func NewMatrix4Rotation(x, y, z float64) Matrix4 {
 	cosx, sinx := math.Cos(x), math.Sin(x)
 	cosy, siny := math.Cos(y), math.Sin(y)
@@ -82,6 +102,21 @@ func NewMatrix4Scaling(x, y, z float64) Matrix4 {
 	}
 }
 
+// View matrix for a camera at eye looking at target, with up at the top of the screen.
+// The camera looks down -Z, with +Y pointing down the screen.
+func NewMatrix4LookAt(eye, target Point3, up Vector3) Matrix4 {
+	back := eye.ToVector3().Sub(target.ToVector3()).Normalize()
+	right := up.Scale(-1).Cross(back).Normalize()
+	down := back.Cross(right)
+	position := eye.ToVector3()
+	return Matrix4{
+		right[0], right[1], right[2], -right.Dot(position),
+		down[0], down[1], down[2], -down.Dot(position),
+		back[0], back[1], back[2], -back.Dot(position),
+		0, 0, 0, 1,
+	}
+}
+
 func (m Matrix4) Row(axis int) Vector4 {
 	o := 4 * axis
 	return Vector4{
@@ -184,6 +219,10 @@ func (m Matrix4) TransformTriangle3(tri Triangle3) Triangle3 {
 	}
 }
 
+func (m Matrix4) Transpose() Matrix4 {
+	return NewMatrix4FromColumns(m.Rows())
+}
+
 // Returns the inverse of the matrix
 func (m Matrix4) Inverse() (Matrix4, error) {
 	inv := Matrix4{}
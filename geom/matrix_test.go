@@ -181,3 +181,46 @@ func TestPerspectiveTransformPoint3(t *testing.T) {
 	expected := Point3{0.905, 2.011, 0.996}
 	assertPoint3Equal(t, result, expected)
 }
+
+func TestTranspose(t *testing.T) {
+	mat := Matrix4{
+		1, 2, 3, 4,
+		5, 6, 7, 8,
+		9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+	expected := Matrix4{
+		1, 5, 9, 13,
+		2, 6, 10, 14,
+		3, 7, 11, 15,
+		4, 8, 12, 16,
+	}
+
+	assertMatrix4Equal(t, mat.Transpose(), expected)
+}
+
+func TestMatrix4Orthographic(t *testing.T) {
+	proj := NewMatrix4Orthographic(2, 10, 1, 101)
+
+	// Depth doesn't change where things appear, and near and far map to -1 and 1
+	assertPoint3Equal(t, proj.TransformPoint3(Point3{10, 5, -1}), Point3{1, 1, -1})
+	assertPoint3Equal(t, proj.TransformPoint3(Point3{-10, -5, -101}), Point3{-1, -1, 1})
+}
+
+func TestMatrix4Isometric(t *testing.T) {
+	proj := NewMatrix4Isometric(1, 2, -10, 10)
+
+	// Each axis is shortened by the same amount
+	origin := proj.TransformPoint3(Point3{0, 0, 0})
+	lengths := []float64{}
+	for _, axis := range []Point3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}} {
+		p := proj.TransformPoint3(axis)
+		lengths = append(lengths, math.Hypot(p[0]-origin[0], p[1]-origin[1]))
+	}
+	assertValuesEqual(t, lengths, []float64{math.Sqrt(2.0 / 3), math.Sqrt(2.0 / 3), math.Sqrt(2.0 / 3)})
+
+	// Looking down, so up is up the screen
+	if up := proj.TransformPoint3(Point3{0, -1, 0}); up[1] >= 0 {
+		t.Errorf("Expected up to be up the screen, got %v", up)
+	}
+}
@@ -0,0 +1,122 @@
+package geom
+
+import "math"
+
+// A rotation, stored as X, Y, Z and W. Unlike Euler angles, rotations can be combined
+// and blended without gimbal lock.
+type Quaternion [4]float64
+
+func NewQuaternionIdentity() Quaternion {
+	return Quaternion{0, 0, 0, 1}
+}
+
+// Rotation by an angle in radians around an axis
+func NewQuaternionFromAxisAngle(axis Vector3, angle float64) Quaternion {
+	axis = axis.Normalize()
+	sin, cos := math.Sincos(angle / 2)
+	return Quaternion{axis[0] * sin, axis[1] * sin, axis[2] * sin, cos}
+}
+
+// Same rotation as NewMatrix4Rotation: around X, then Y, then Z
+func NewQuaternionFromEuler(x, y, z float64) Quaternion {
+	qx := NewQuaternionFromAxisAngle(Vector3{1, 0, 0}, x)
+	qy := NewQuaternionFromAxisAngle(Vector3{0, 1, 0}, y)
+	qz := NewQuaternionFromAxisAngle(Vector3{0, 0, 1}, z)
+	return qz.Multiply(qy).Multiply(qx)
+}
+
+// Rotation of a matrix, which must be a rotation without any scaling
+func NewQuaternionFromMatrix4(m Matrix4) Quaternion {
+	m00, m11, m22 := m.Get(0, 0), m.Get(1, 1), m.Get(2, 2)
+	var q Quaternion
+	// Work from the largest component, so it's never divided by something tiny
+	switch trace := m00 + m11 + m22; {
+	case trace > 0:
+		s := 2 * math.Sqrt(trace+1)
+		q = Quaternion{(m.Get(2, 1) - m.Get(1, 2)) / s, (m.Get(0, 2) - m.Get(2, 0)) / s, (m.Get(1, 0) - m.Get(0, 1)) / s, s / 4}
+	case m00 > m11 && m00 > m22:
+		s := 2 * math.Sqrt(1+m00-m11-m22)
+		q = Quaternion{s / 4, (m.Get(0, 1) + m.Get(1, 0)) / s, (m.Get(0, 2) + m.Get(2, 0)) / s, (m.Get(2, 1) - m.Get(1, 2)) / s}
+	case m11 > m22:
+		s := 2 * math.Sqrt(1+m11-m00-m22)
+		q = Quaternion{(m.Get(0, 1) + m.Get(1, 0)) / s, s / 4, (m.Get(1, 2) + m.Get(2, 1)) / s, (m.Get(0, 2) - m.Get(2, 0)) / s}
+	default:
+		s := 2 * math.Sqrt(1+m22-m00-m11)
+		q = Quaternion{(m.Get(0, 2) + m.Get(2, 0)) / s, (m.Get(1, 2) + m.Get(2, 1)) / s, s / 4, (m.Get(1, 0) - m.Get(0, 1)) / s}
+	}
+	return q.Normalize()
+}
+
+// Rotation by other followed by q
+func (q Quaternion) Multiply(other Quaternion) Quaternion {
+	x1, y1, z1, w1 := q[0], q[1], q[2], q[3]
+	x2, y2, z2, w2 := other[0], other[1], other[2], other[3]
+	return Quaternion{
+		w1*x2 + x1*w2 + y1*z2 - z1*y2,
+		w1*y2 - x1*z2 + y1*w2 + z1*x2,
+		w1*z2 + x1*y2 - y1*x2 + z1*w2,
+		w1*w2 - x1*x2 - y1*y2 - z1*z2,
+	}
+}
+
+func (q Quaternion) Dot(other Quaternion) float64 {
+	return q[0]*other[0] + q[1]*other[1] + q[2]*other[2] + q[3]*other[3]
+}
+
+func (q Quaternion) Normalize() Quaternion {
+	length := math.Sqrt(q.Dot(q))
+	if length == 0 {
+		return NewQuaternionIdentity()
+	}
+	return Quaternion{q[0] / length, q[1] / length, q[2] / length, q[3] / length}
+}
+
+// The opposite rotation, for a normalized quaternion
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{-q[0], -q[1], -q[2], q[3]}
+}
+
+func (q Quaternion) RotateVector3(v Vector3) Vector3 {
+	p := q.Multiply(Quaternion{v[0], v[1], v[2], 0}).Multiply(q.Conjugate())
+	return Vector3{p[0], p[1], p[2]}
+}
+
+// Blends between two rotations at a constant speed, the short way round. t goes from 0 to 1.
+func (q Quaternion) Slerp(other Quaternion, t float64) Quaternion {
+	cos := q.Dot(other)
+	if cos < 0 {
+		other = Quaternion{-other[0], -other[1], -other[2], -other[3]}
+		cos = -cos
+	}
+
+	// Nearly the same rotation, where sin(angle) is too small to divide by
+	if cos > 0.9995 {
+		return Quaternion{
+			q[0] + (other[0]-q[0])*t,
+			q[1] + (other[1]-q[1])*t,
+			q[2] + (other[2]-q[2])*t,
+			q[3] + (other[3]-q[3])*t,
+		}.Normalize()
+	}
+
+	angle := math.Acos(cos)
+	sin := math.Sin(angle)
+	a := math.Sin((1-t)*angle) / sin
+	b := math.Sin(t*angle) / sin
+	return Quaternion{
+		q[0]*a + other[0]*b,
+		q[1]*a + other[1]*b,
+		q[2]*a + other[2]*b,
+		q[3]*a + other[3]*b,
+	}
+}
+
+func (q Quaternion) ToMatrix4() Matrix4 {
+	x, y, z, w := q[0], q[1], q[2], q[3]
+	return Matrix4{
+		1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w), 0,
+		2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w), 0,
+		2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y), 0,
+		0, 0, 0, 1,
+	}
+}
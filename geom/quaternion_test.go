@@ -0,0 +1,65 @@
+package geom
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuaternionFromEuler(t *testing.T) {
+	for _, angles := range []Vector3{{0, 0, 0}, {0.3, 0, 0}, {0, -1.2, 0}, {0, 0, 2.5}, {0.4, 1.1, -0.7}, {math.Pi / 2, math.Pi / 2, 0}} {
+		q := NewQuaternionFromEuler(angles[0], angles[1], angles[2])
+		assertMatrix4Equal(t, q.ToMatrix4(), NewMatrix4Rotation(angles[0], angles[1], angles[2]))
+	}
+}
+
+func TestQuaternionFromAxisAngle(t *testing.T) {
+	q := NewQuaternionFromAxisAngle(Vector3{0, 0, 2}, math.Pi/2)
+	v := q.RotateVector3(Vector3{1, 0, 0})
+	assertValuesEqual(t, v[:], []float64{0, 1, 0})
+
+	// Rotating by q then its conjugate goes back again
+	q = NewQuaternionFromAxisAngle(Vector3{1, 2, 3}, 0.8)
+	v = q.Conjugate().RotateVector3(q.RotateVector3(Vector3{4, 5, 6}))
+	assertValuesEqual(t, v[:], []float64{4, 5, 6})
+}
+
+func TestQuaternionFromMatrix4(t *testing.T) {
+	// Each branch, depending on which component is largest
+	for _, angles := range []Vector3{{0.1, 0.2, 0.3}, {math.Pi, 0, 0}, {0, math.Pi, 0}, {0, 0, math.Pi}, {2, -1, 3}} {
+		m := NewMatrix4Rotation(angles[0], angles[1], angles[2])
+		assertMatrix4Equal(t, NewQuaternionFromMatrix4(m).ToMatrix4(), m)
+	}
+}
+
+func TestQuaternionSlerp(t *testing.T) {
+	from := NewQuaternionIdentity()
+	to := NewQuaternionFromAxisAngle(Vector3{0, 1, 0}, math.Pi/2)
+
+	half, quarter := from.Slerp(to, 0.5), NewQuaternionFromAxisAngle(Vector3{0, 1, 0}, math.Pi/4)
+	assertValuesEqual(t, half[:], quarter[:])
+	start, end := from.Slerp(to, 0), from.Slerp(to, 1)
+	assertValuesEqual(t, start[:], from[:])
+	assertValuesEqual(t, end[:], to[:])
+
+	// The same rotation with every sign flipped still takes the short way
+	flipped := Quaternion{-to[0], -to[1], -to[2], -to[3]}
+	half = from.Slerp(flipped, 0.5)
+	assertMatrix4Equal(t, half.ToMatrix4(), NewMatrix4Rotation(0, math.Pi/4, 0))
+}
+
+func TestMatrix4LookAt(t *testing.T) {
+	up := Vector3{0, -1, 0}
+
+	// Looking down -Z is no rotation
+	view := NewMatrix4LookAt(Point3{0, 0, 10}, Point3{0, 0, 0}, up)
+	assertMatrix4Equal(t, view, NewMatrix4Translation(0, 0, -10))
+
+	// The target ends up straight ahead, and up stays up the screen
+	eye, target := Point3{3, -4, 5}, Point3{-1, 2, 0}
+	view = NewMatrix4LookAt(eye, target, up)
+	distance := target.ToVector3().Sub(eye.ToVector3()).Magnitude()
+	assertPoint3Equal(t, view.TransformPoint3(target), Point3{0, 0, -distance})
+	if above := view.TransformPoint3(Point3{-1, 1, 0}); above[1] >= 0 {
+		t.Errorf("Expected a point above the target to be up the screen, got %v", above)
+	}
+}
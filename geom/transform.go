@@ -2,7 +2,10 @@ package geom
 
 type Transform struct {
 	Translation Vector3
-	Rotation    Vector3
+	// Euler angles in radians
+	Rotation Vector3
+	// Turns after Rotation. Left as zero to only use Rotation.
+	Orientation Quaternion
 	Scaling     Vector3
 }
 
@@ -23,11 +26,15 @@ func (t *Transform) TranslationMatrix() Matrix4 {
 }
 
 func (t *Transform) RotationMatrix() Matrix4 {
-	return NewMatrix4Rotation(
+	rotation := NewMatrix4Rotation(
 		t.Rotation[0],
 		t.Rotation[1],
 		t.Rotation[2],
 	)
+	if t.Orientation != (Quaternion{}) {
+		rotation = t.Orientation.ToMatrix4().Multiply(rotation)
+	}
+	return rotation
 }
 
 func (t *Transform) ScalingMatrix() Matrix4 {
@@ -101,6 +101,24 @@ func (v Vector3) Scale(scale float64) Vector3 {
 	}
 }
 
+// Multiply each component by the matching component of another vector
+func (v1 Vector3) Multiply(v2 Vector3) Vector3 {
+	return Vector3{
+		v1[0] * v2[0],
+		v1[1] * v2[1],
+		v1[2] * v2[2],
+	}
+}
+
+// Limit each component to between min and max
+func (v Vector3) Clamp(min, max float64) Vector3 {
+	return Vector3{
+		Min(Max(v[0], min), max),
+		Min(Max(v[1], min), max),
+		Min(Max(v[2], min), max),
+	}
+}
+
 // Get the dot product between two vectors
 func (left Vector3) Dot(right Vector3) float64 {
 	dot := 0.0
@@ -122,13 +140,15 @@ func (p *Point3) Vector3FromPoint3() Vector3 {
 	return Vector3{p[0], p[1], p[2]}
 }
 
-// Convert a vector storing RGB into a single 32bit integer: 0x00RRGGBB
+// Convert a vector storing RGB into a single, opaque, 32bit integer: 0xffRRGGBB
+// Components outside 0.0 to 1.0 are clamped.
 func (v Vector3) ToColor() Color {
+	v = v.Clamp(0, 1)
 	r := uint32(v.X()*0xff) << 16
 	g := uint32(v.Y()*0xff) << 8
 	b := uint32(v.Z()*0xff) << 0
 
-	return Color(r + g + b)
+	return Color(0xff000000 + r + g + b)
 }
 
 // Convert a 32bit uint (0x00RRGGBB) into a vector storing RGB
@@ -124,6 +124,27 @@ func NewLineMeshCube() LineMesh {
 			Line{7, 3},
 			Line{3, 2},
 		},
+		Colors: []uint32{
+			0xffff0000,
+			0xffff0000,
+			0xffff0000,
+			0xffff0000,
+
+			0xff00ff00,
+			0xff00ff00,
+			0xff00ff00,
+			0xff00ff00,
+
+			0xffff00ff,
+			0xffff00ff,
+			0xffff00ff,
+			0xffff00ff,
+
+			0xff00ffff,
+			0xff00ffff,
+			0xff00ffff,
+			0xff00ffff,
+		},
 	}
 
 }
@@ -6,8 +6,23 @@ import (
 )
 
 func (m *TriangleMesh) DrawTriangles(ch chan<- Polygon) {
+	m.DrawTransformedTriangles(NewMatrix4Identity(), ch)
+}
+
+// Draws the mesh inside another transform, such as its parent's in a scene graph
+func (m *TriangleMesh) DrawTransformedTriangles(parent Matrix4, ch chan<- Polygon) {
 	// Move to world space
-	model := m.Transform.Matrix()
+	model := parent.Multiply(m.Transform.Matrix())
+
+	// Without a normal for every vertex, smooth shading has nothing to go on
+	shading := m.Shading
+	if len(m.VertexNormals) != len(m.Vertices) {
+		shading = FlatShading
+	}
+	// Normals need the inverse transpose so they stay perpendicular when scaled
+	inverse, _ := model.Inverse()
+	normalMatrix := inverse.Transpose()
+
 	for i, triIndexes := range m.Triangles {
 		triangle := model.TransformTriangle3(Triangle3{
 			m.Vertices[triIndexes[0]],
@@ -15,6 +30,58 @@ func (m *TriangleMesh) DrawTriangles(ch chan<- Polygon) {
 			m.Vertices[triIndexes[2]],
 		})
 		color := m.Colors[i]
-		ch <- Polygon{Shape: triangle, Color: color}
+		texture := m.Texture
+		var material *Material
+		if i < len(m.FaceMaterials) && m.FaceMaterials[i] >= 0 {
+			material = &m.Materials[m.FaceMaterials[i]]
+			if material.DiffuseTexture != nil {
+				texture = material.DiffuseTexture
+			}
+		}
+
+		uvs := [3]Vector2{}
+		if len(m.UVs) == len(m.Vertices) {
+			for j, idx := range triIndexes {
+				uvs[j] = m.UVs[idx]
+			}
+		} else {
+			texture = nil
+		}
+
+		normals := [3]Vector3{}
+		if shading != FlatShading {
+			for j, idx := range triIndexes {
+				normal := normalMatrix.TransformVector3(m.VertexNormals[idx])
+				if normal.Magnitude() > 0 {
+					normals[j] = normal.Normalize()
+				}
+			}
+		}
+
+		ch <- Polygon{
+			Shape:    triangle,
+			Color:    color,
+			Material: material,
+			Normals:  normals,
+			Shading:  shading,
+			Texture:  texture,
+			UVs:      uvs,
+		}
+	}
+}
+
+func (m *LineMesh) DrawLines(ch chan<- LineSegment) {
+	// Move to world space
+	model := m.Transform.Matrix()
+	for i, lineIndexes := range m.Lines {
+		line := Line3{
+			model.TransformPoint3(m.Vertices[lineIndexes[0]]),
+			model.TransformPoint3(m.Vertices[lineIndexes[1]]),
+		}
+		color := uint32(0xffffffff)
+		if i < len(m.Colors) {
+			color = m.Colors[i]
+		}
+		ch <- LineSegment{Shape: line, Color: color}
 	}
 }
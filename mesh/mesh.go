@@ -2,6 +2,8 @@ package mesh
 
 import (
 	. "tri/geom"
+	. "tri/renderer"
+	. "tri/texture"
 )
 
 type Line [2]int
@@ -10,14 +12,36 @@ type LineMesh struct {
 	Transform Transform
 	Vertices  []Point3
 	Lines     []Line
+	Colors    []uint32
 }
 
+// Normals and Colors have one entry per triangle.
+// VertexNormals and UVs are optional, and have one entry per vertex.
 type TriangleMesh struct {
-	Transform Transform
-	Vertices  []Point3
-	Triangles [][3]int
-	Normals   []Vector3
-	Colors    []uint32
+	Transform     Transform
+	Vertices      []Point3
+	Triangles     [][3]int
+	Normals       []Vector3
+	Colors        []uint32
+	VertexNormals []Vector3
+	UVs           []Vector2
+	Groups        []Group
+	Materials     []Material
+	// Index into Materials for each triangle, or -1 for none
+	FaceMaterials []int
+	// Smooth shading needs VertexNormals, and is drawn flat without them. SetShading adds them.
+	Shading Shading
+	// Drawn over triangles using the UVs, unless their material has its own texture
+	Texture *Texture
+}
+
+// A named run of triangles within a mesh
+type Group struct {
+	Object   string
+	Name     string
+	Material string
+	Start    int
+	Count    int
 }
 
 func (m *TriangleMesh) Triangle(index int) Triangle3 {
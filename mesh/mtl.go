@@ -0,0 +1,143 @@
+package mesh
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	. "tri/geom"
+	. "tri/renderer"
+	. "tri/texture"
+)
+
+// Reads a Wavefront MTL material library from a file.
+// Texture paths are made relative to the library, and any that exist are loaded.
+func NewMaterialsFromMtlPath(path string) ([]Material, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p := mtlParser{}
+	p.file = path
+	if err := p.read(f, p.parseLine); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	for i := range p.materials {
+		diffuseMap := p.materials[i].DiffuseMap
+		if diffuseMap == "" {
+			continue
+		}
+		if !filepath.IsAbs(diffuseMap) {
+			diffuseMap = filepath.Join(dir, diffuseMap)
+			p.materials[i].DiffuseMap = diffuseMap
+		}
+
+		texture, err := NewTextureFromPath(diffuseMap)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.materials[i].DiffuseTexture = texture
+	}
+
+	return p.materials, nil
+}
+
+// Reads a Wavefront MTL material library
+// See: http://paulbourke.net/dataformats/mtl/
+func NewMaterialsFromMtl(r io.Reader) ([]Material, error) {
+	p := mtlParser{}
+	if err := p.read(r, p.parseLine); err != nil {
+		return nil, err
+	}
+
+	return p.materials, nil
+}
+
+type mtlParser struct {
+	lineParser
+	materials []Material
+}
+
+func (p *mtlParser) parseLine(tokens []string) error {
+	args := tokens[1:]
+
+	if tokens[0] == "newmtl" {
+		if len(args) == 0 {
+			return p.errorf("material has no name")
+		}
+		p.materials = append(p.materials, NewMaterial(strings.Join(args, " ")))
+		return nil
+	}
+
+	if len(p.materials) == 0 {
+		return p.errorf("%s before newmtl", tokens[0])
+	}
+	material := &p.materials[len(p.materials)-1]
+
+	switch tokens[0] {
+	case "Ka": // Ambient
+		return p.parseColor(args, &material.Ambient)
+
+	case "Kd": // Diffuse
+		return p.parseColor(args, &material.Diffuse)
+
+	case "Ks": // Specular
+		return p.parseColor(args, &material.Specular)
+
+	case "Ns": // Specular exponent
+		values, err := p.parseFloats(args, 1, 1)
+		if err != nil {
+			return err
+		}
+		material.Shininess = values[0]
+
+	case "d": // Dissolve
+		values, err := p.parseFloats(args, 1, 1)
+		if err != nil {
+			return err
+		}
+		material.Opacity = values[0]
+
+	case "Tr": // Transparency
+		values, err := p.parseFloats(args, 1, 1)
+		if err != nil {
+			return err
+		}
+		material.Opacity = 1.0 - values[0]
+
+	case "map_Kd": // Diffuse texture
+		if len(args) == 0 {
+			return p.errorf("map_Kd has no file")
+		}
+		// Options come before the file name
+		material.DiffuseMap = args[len(args)-1]
+	}
+
+	return nil
+}
+
+// Parses an RGB colour, or a single value for grey
+func (p *mtlParser) parseColor(args []string, color *Vector3) error {
+	values, err := p.parseFloats(args, 1, 3)
+	if err != nil {
+		return err
+	}
+
+	switch len(values) {
+	case 1:
+		*color = Vector3{values[0], values[0], values[0]}
+	case 3:
+		*color = Vector3{values[0], values[1], values[2]}
+	default:
+		return p.errorf("expected 1 or 3 values, got %d", len(values))
+	}
+
+	return nil
+}
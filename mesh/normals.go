@@ -0,0 +1,46 @@
+package mesh
+
+import (
+	. "tri/geom"
+	. "tri/renderer"
+)
+
+// Sets VertexNormals to the average normal of every face touching each vertex.
+// Vertices in the same place are treated as one, so meshes that don't share
+// vertices between faces still come out smooth.
+func (m *TriangleMesh) ComputeVertexNormals() {
+	m.VertexNormals = m.averageFaceNormals()
+}
+
+// Changes how the mesh is shaded. Gouraud and Phong shading need VertexNormals,
+// so they're worked out here if the mesh doesn't have them.
+func (m *TriangleMesh) SetShading(shading Shading) {
+	m.Shading = shading
+	if shading != FlatShading && len(m.VertexNormals) != len(m.Vertices) {
+		m.ComputeVertexNormals()
+	}
+}
+
+func (m *TriangleMesh) averageFaceNormals() []Vector3 {
+	sums := map[Point3]Vector3{}
+	for i := range m.Triangles {
+		tri := m.Triangle(i)
+		// Larger faces have more influence
+		line1 := tri[2].ToVector3().Sub(tri[0].ToVector3())
+		line2 := tri[1].ToVector3().Sub(tri[0].ToVector3())
+		normal := line1.Cross(line2)
+		for _, vertex := range tri {
+			sums[vertex] = sums[vertex].Add(normal)
+		}
+	}
+
+	normals := make([]Vector3, len(m.Vertices))
+	for i, vertex := range m.Vertices {
+		sum := sums[vertex]
+		if sum.Magnitude() > 0 {
+			normals[i] = sum.Normalize()
+		}
+	}
+
+	return normals
+}
@@ -0,0 +1,67 @@
+package mesh
+
+import (
+	"testing"
+	. "tri/geom"
+	. "tri/renderer"
+)
+
+// Two faces meeting at a right angle along the Y axis, with separate vertices for each
+func newFoldMesh() TriangleMesh {
+	return TriangleMesh{
+		Vertices: []Point3{
+			{0, 0, 0}, {1, 0, 0}, {0, 1, 0},
+			{0, 0, 0}, {0, 1, 0}, {0, 0, 1},
+		},
+		Triangles: [][3]int{{0, 1, 2}, {3, 4, 5}},
+		Colors:    []uint32{0xffffffff, 0xffffffff},
+	}
+}
+
+func TestComputeVertexNormals(t *testing.T) {
+	mesh := newFoldMesh()
+	mesh.ComputeVertexNormals()
+
+	// Corners on the fold share both faces' normals, the rest only have their own
+	fold := Vector3{-1, 0, -1}.Normalize()
+	expected := []Vector3{fold, {0, 0, -1}, fold, fold, fold, {-1, 0, 0}}
+	for i, normal := range mesh.VertexNormals {
+		if normal.Sub(expected[i]).Magnitude() > 1e-9 {
+			t.Errorf("Vertex %d: expected normal %v, got %v", i, expected[i], normal)
+		}
+	}
+}
+
+func TestSetShading(t *testing.T) {
+	mesh := newFoldMesh()
+	mesh.SetShading(FlatShading)
+	if mesh.VertexNormals != nil {
+		t.Errorf("Expected flat shading not to need normals, got %v", mesh.VertexNormals)
+	}
+
+	mesh.SetShading(GouraudShading)
+	if len(mesh.VertexNormals) != len(mesh.Vertices) {
+		t.Fatalf("Expected a normal for every vertex, got %v", mesh.VertexNormals)
+	}
+
+	// Normals that are already there are kept
+	mesh.VertexNormals[1] = Vector3{0, 1, 0}
+	mesh.SetShading(PhongShading)
+	if mesh.VertexNormals[1] != (Vector3{0, 1, 0}) {
+		t.Errorf("Expected the mesh's own normals to be kept, got %v", mesh.VertexNormals[1])
+	}
+}
+
+func TestDrawWithoutVertexNormals(t *testing.T) {
+	mesh := newFoldMesh()
+	mesh.Shading = PhongShading
+
+	ch := make(chan Polygon, len(mesh.Triangles))
+	mesh.DrawTriangles(ch)
+	close(ch)
+	for poly := range ch {
+		if poly.Shading != FlatShading {
+			t.Errorf("Expected a mesh without normals to be drawn flat, got %d", poly.Shading)
+		}
+	}
+}
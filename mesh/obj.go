@@ -2,75 +2,367 @@ package mesh
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	. "tri/geom"
 )
 
-func NewMeshFromObjPath(path string) (TriangleMesh, error) {
-	mesh := TriangleMesh{}
+// An error found while reading a Wavefront OBJ or MTL file
+type ObjError struct {
+	File string
+	Line int
+	Msg  string
+}
 
+func (e *ObjError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("obj: line %d: %s", e.Line, e.Msg)
+}
+
+// Reads a Wavefront OBJ model from a file, along with any MTL material
+// libraries it references.
+func NewMeshFromObjPath(path string) (TriangleMesh, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return mesh, err
+		return TriangleMesh{}, err
+	}
+	defer f.Close()
+
+	p := newObjParser()
+	p.file = path
+	p.dir = filepath.Dir(path)
+	if err := p.read(f, p.parseLine); err != nil {
+		return TriangleMesh{}, err
+	}
+
+	return p.finish(), nil
+}
+
+// Reads a Wavefront OBJ model. Faces with more than 3 sides are split into triangles.
+// Coordinates are kept exactly as they are in the file.
+// Material libraries can't be found without a path, so `mtllib` is ignored.
+// See: http://paulbourke.net/dataformats/obj/
+func NewMeshFromObj(r io.Reader) (TriangleMesh, error) {
+	p := newObjParser()
+	if err := p.read(r, p.parseLine); err != nil {
+		return TriangleMesh{}, err
+	}
+
+	return p.finish(), nil
+}
+
+// Line based reader shared by the OBJ and MTL parsers
+type lineParser struct {
+	file string
+	line int
+}
+
+// Calls parse for every line, with comments stripped, split into tokens
+func (p *lineParser) read(r io.Reader, parse func(tokens []string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		p.line += 1
+
+		line := scanner.Text()
+		if comment := strings.IndexByte(line, '#'); comment >= 0 {
+			line = line[:comment]
+		}
+		tokens := strings.Fields(line)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		if err := parse(tokens); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (p *lineParser) errorf(format string, a ...interface{}) error {
+	return &ObjError{File: p.file, Line: p.line, Msg: fmt.Sprintf(format, a...)}
+}
+
+func (p *lineParser) parseFloats(args []string, min, max int) ([]float64, error) {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return nil, p.errorf("expected %d values, got %d", min, len(args))
+		}
+		return nil, p.errorf("expected %d to %d values, got %d", min, max, len(args))
+	}
+
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		value, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", arg)
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+type objParser struct {
+	lineParser
+	mesh      TriangleMesh
+	dir       string
+	positions []Point3
+	uvs       []Vector2
+	normals   []Vector3
+	// Mesh vertex for each unique v/vt/vn combination
+	vertices map[[3]int]int
+	hasUVs   bool
+	hasNorms bool
+	object   string
+	group    string
+	material string
+	// Index into mesh.Materials by name
+	materials map[string]int
+}
+
+func newObjParser() *objParser {
+	return &objParser{
+		mesh: TriangleMesh{
+			Transform: NewTransform(),
+			Vertices:  []Point3{},
+			Triangles: [][3]int{},
+			Normals:   []Vector3{},
+			Colors:    []uint32{},
+		},
+		vertices:  map[[3]int]int{},
+		materials: map[string]int{},
+	}
+}
+
+func (p *objParser) parseLine(tokens []string) error {
+	args := tokens[1:]
+	switch tokens[0] {
+	case "v": // Vertex
+		values, err := p.parseFloats(args, 3, 4)
+		if err != nil {
+			return err
+		}
+		p.positions = append(p.positions, Point3{values[0], values[1], values[2]})
+
+	case "vt": // Vertex Texture
+		values, err := p.parseFloats(args, 1, 3)
+		if err != nil {
+			return err
+		}
+		uv := Vector2{values[0], 0}
+		if len(values) > 1 {
+			uv[1] = values[1]
+		}
+		p.uvs = append(p.uvs, uv)
+
+	case "vn": // Vertex Normal
+		values, err := p.parseFloats(args, 3, 3)
+		if err != nil {
+			return err
+		}
+		p.normals = append(p.normals, Vector3{values[0], values[1], values[2]})
+
+	case "f": // Face
+		return p.parseFace(args)
+
+	case "o": // Object
+		p.startGroup()
+		p.object = strings.Join(args, " ")
+		p.group = ""
+
+	case "g": // Group
+		p.startGroup()
+		p.group = strings.Join(args, " ")
+
+	case "usemtl": // Material
+		p.startGroup()
+		p.material = strings.Join(args, " ")
+
+	case "mtllib": // Material library
+		return p.loadMaterials(args)
 	}
 
-	reader := bufio.NewReader(f)
-	for {
-		line, err := reader.ReadString('\n')
-		if err == io.EOF {
-			break
+	return nil
+}
+
+func (p *objParser) loadMaterials(names []string) error {
+	if p.dir == "" {
+		return nil
+	}
+
+	for _, name := range names {
+		materials, err := NewMaterialsFromMtlPath(filepath.Join(p.dir, name))
+		if os.IsNotExist(err) {
+			// Exporters often reference libraries that were never saved
+			continue
+		}
+		if err != nil {
+			return err
 		}
 
-		cat := strings.Split(line, " ")[0]
-		switch cat {
-		case "v": // Vertex
-			mesh.Vertices = append(mesh.Vertices, parseVertex(line))
-		case "f": // Face
-			mesh.Triangles = append(mesh.Triangles, parseFace(line))
-			mesh.Colors = append(mesh.Colors, 0xffaaaaaa)
-		case "vt": // Vertex Texture
-		case "vn": // Vertex Normal
+		for _, material := range materials {
+			p.materials[material.Name] = len(p.mesh.Materials)
+			p.mesh.Materials = append(p.mesh.Materials, material)
 		}
 	}
 
-	return mesh, nil
+	return nil
 }
 
-func parseVertex(line string) Point3 {
-	p := Point3{}
+// Resolves an OBJ index, which starts at 1 or is negative to count back from the end
+func (p *objParser) parseIndex(token string, count int, name string) (int, error) {
+	idx, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, p.errorf("invalid %s index %q", name, token)
+	}
 
-	tokens := strings.Split(line, " ")
+	if idx < 0 {
+		idx = count + idx
+	} else {
+		idx -= 1
+	}
 
-	for i := 0; i < 3; i++ {
-		p[i], _ = strconv.ParseFloat(strings.TrimSpace(tokens[i+1]), 64)
-		p[i] *= -1
+	if idx < 0 || idx >= count {
+		return 0, p.errorf("%s index %s out of range", name, token)
 	}
-	/*
-		if len(tokens) > 4 {
-			w, _ := strconv.ParseFloat(tokens[4], 64)
-			p[0] /= w
-			p[1] /= w
-			p[2] /= w
+
+	return idx, nil
+}
+
+// Parses a v, v/vt, v//vn or v/vt/vn face corner into a mesh vertex index
+func (p *objParser) parseCorner(token string) (int, error) {
+	parts := strings.Split(token, "/")
+	if len(parts) > 3 {
+		return 0, p.errorf("invalid face vertex %q", token)
+	}
+
+	key := [3]int{-1, -1, -1}
+	var err error
+
+	key[0], err = p.parseIndex(parts[0], len(p.positions), "vertex")
+	if err != nil {
+		return 0, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		key[1], err = p.parseIndex(parts[1], len(p.uvs), "texture")
+		if err != nil {
+			return 0, err
+		}
+		p.hasUVs = true
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		key[2], err = p.parseIndex(parts[2], len(p.normals), "normal")
+		if err != nil {
+			return 0, err
 		}
-	*/
+		p.hasNorms = true
+	}
+
+	if idx, ok := p.vertices[key]; ok {
+		return idx, nil
+	}
 
-	return p
+	idx := len(p.mesh.Vertices)
+	p.vertices[key] = idx
+	p.mesh.Vertices = append(p.mesh.Vertices, p.positions[key[0]])
+
+	uv := Vector2{}
+	if key[1] >= 0 {
+		uv = p.uvs[key[1]]
+	}
+	p.mesh.UVs = append(p.mesh.UVs, uv)
+
+	normal := Vector3{}
+	if key[2] >= 0 {
+		normal = p.normals[key[2]]
+	}
+	p.mesh.VertexNormals = append(p.mesh.VertexNormals, normal)
+
+	return idx, nil
+}
+
+func (p *objParser) parseFace(args []string) error {
+	if len(args) < 3 {
+		return p.errorf("face needs at least 3 vertices, got %d", len(args))
+	}
+
+	corners := make([]int, len(args))
+	for i, arg := range args {
+		idx, err := p.parseCorner(arg)
+		if err != nil {
+			return err
+		}
+		corners[i] = idx
+	}
+
+	// Split polygons into a fan of triangles
+	for i := 1; i < len(corners)-1; i++ {
+		p.mesh.Triangles = append(p.mesh.Triangles, [3]int{corners[0], corners[i], corners[i+1]})
+		p.mesh.Colors = append(p.mesh.Colors, 0xffaaaaaa)
+	}
+
+	return nil
+}
+
+// Closes the current group, if it has any faces in it
+func (p *objParser) startGroup() {
+	start := 0
+	if len(p.mesh.Groups) > 0 {
+		last := p.mesh.Groups[len(p.mesh.Groups)-1]
+		start = last.Start + last.Count
+	}
+
+	count := len(p.mesh.Triangles) - start
+	if count == 0 {
+		return
+	}
+
+	p.mesh.Groups = append(p.mesh.Groups, Group{
+		Object:   p.object,
+		Name:     p.group,
+		Material: p.material,
+		Start:    start,
+		Count:    count,
+	})
 }
 
-func parseFace(line string) [3]int {
-	tri := [3]int{}
+func (p *objParser) finish() TriangleMesh {
+	p.startGroup()
 
-	tokens := strings.Split(line, " ")
+	if !p.hasUVs {
+		p.mesh.UVs = nil
+	}
+	if !p.hasNorms {
+		p.mesh.VertexNormals = nil
+	}
 
-	for i := 0; i < 3; i++ {
-		indexes := strings.Split(strings.TrimSpace(tokens[i+1]), "/")
-		idx, _ := strconv.ParseInt(indexes[0], 10, 64)
+	if len(p.mesh.Materials) > 0 {
+		p.mesh.FaceMaterials = make([]int, len(p.mesh.Triangles))
+		for i := range p.mesh.FaceMaterials {
+			p.mesh.FaceMaterials[i] = -1
+		}
 
-		tri[i] = int(idx - 1)
+		for _, group := range p.mesh.Groups {
+			idx, ok := p.materials[group.Material]
+			if !ok {
+				continue
+			}
+			color := uint32(p.mesh.Materials[idx].Diffuse.ToColor())
+			for i := group.Start; i < group.Start+group.Count; i++ {
+				p.mesh.FaceMaterials[i] = idx
+				p.mesh.Colors[i] = color
+			}
+		}
 	}
 
-	return tri
+	return p.mesh
 }
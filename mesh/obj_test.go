@@ -0,0 +1,157 @@
+package mesh
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	. "tri/geom"
+)
+
+func TestNewMeshFromObjQuad(t *testing.T) {
+	obj := `# A quad exported from Blender
+o Plane
+v -1.0  0.0  1.0
+v  1.0  0.0  1.0
+v  1.0  0.0 -1.0
+v -1.0  0.0 -1.0
+vt 0.0 0.0
+vt 1.0 0.0
+vt 1.0 1.0
+vt 0.0 1.0
+vn 0.0 1.0 0.0
+usemtl Grass
+f 1/1/1 2/2/1 3/3/1 4/4/1`
+
+	mesh, err := NewMeshFromObj(strings.NewReader(obj))
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ: %v", err)
+	}
+
+	if len(mesh.Triangles) != 2 {
+		t.Fatalf("Expected 2 triangles, got %d", len(mesh.Triangles))
+	}
+	if mesh.Triangles[0] != [3]int{0, 1, 2} || mesh.Triangles[1] != [3]int{0, 2, 3} {
+		t.Errorf("Quad was triangulated wrong: %v", mesh.Triangles)
+	}
+	if len(mesh.Colors) != 2 {
+		t.Errorf("Expected a colour per triangle, got %d", len(mesh.Colors))
+	}
+	if mesh.Vertices[0][0] != -1.0 || mesh.Vertices[0][2] != 1.0 {
+		t.Errorf("Vertex was changed while loading: %v", mesh.Vertices[0])
+	}
+	if len(mesh.UVs) != 4 || mesh.UVs[2][0] != 1.0 || mesh.UVs[2][1] != 1.0 {
+		t.Errorf("UVs are wrong: %v", mesh.UVs)
+	}
+	if len(mesh.VertexNormals) != 4 || mesh.VertexNormals[3][1] != 1.0 {
+		t.Errorf("Normals are wrong: %v", mesh.VertexNormals)
+	}
+
+	expected := Group{Object: "Plane", Material: "Grass", Start: 0, Count: 2}
+	if len(mesh.Groups) != 1 || mesh.Groups[0] != expected {
+		t.Errorf("Groups are wrong: %#v", mesh.Groups)
+	}
+}
+
+func TestNewMeshFromObjRelativeIndexes(t *testing.T) {
+	obj := "v 0 0 0\nv 1 0 0\nv 0 1 0\nvn 0 0 1\nf -3//-1 -2//-1 -1//-1\nf 1 2 3\n"
+
+	mesh, err := NewMeshFromObj(strings.NewReader(obj))
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ: %v", err)
+	}
+
+	if mesh.Triangles[0] != [3]int{0, 1, 2} {
+		t.Errorf("Relative indexes resolved wrong: %v", mesh.Triangles[0])
+	}
+	// Same positions without normals are different vertices
+	if mesh.Triangles[1] != [3]int{3, 4, 5} {
+		t.Errorf("Vertices were shared wrongly: %v", mesh.Triangles[1])
+	}
+	if mesh.UVs != nil {
+		t.Errorf("Expected no UVs, got %v", mesh.UVs)
+	}
+}
+
+func TestNewMeshFromObjErrors(t *testing.T) {
+	tests := []struct {
+		obj  string
+		line int
+	}{
+		{"v 0 0 0\nv 1 0\n", 2},
+		{"v 0 0 0\nv 1 0 0\nv 1 one 0\n", 3},
+		{"v 0 0 0\nv 1 0 0\n\nf 1 2\n", 4},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n", 4},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1 2/1 3/1\n", 4},
+	}
+
+	for _, test := range tests {
+		_, err := NewMeshFromObj(strings.NewReader(test.obj))
+
+		var objErr *ObjError
+		if !errors.As(err, &objErr) {
+			t.Errorf("Expected an ObjError for %q, got %v", test.obj, err)
+			continue
+		}
+		if objErr.Line != test.line {
+			t.Errorf("Expected error on line %d, got %d (%v)", test.line, objErr.Line, err)
+		}
+	}
+}
+
+func TestNewMeshFromObjPath(t *testing.T) {
+	mesh, err := NewMeshFromObjPath("../assets/suzanne.obj")
+	if err != nil {
+		t.Fatalf("Failed to load Suzanne: %v", err)
+	}
+	if len(mesh.Triangles) == 0 {
+		t.Errorf("Suzanne has no triangles")
+	}
+}
+
+func TestNewMeshFromObjPathWithMaterials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tri-obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	obj := "mtllib box.mtl missing.mtl\nv 0 0 0\nv 1 0 0\nv 0 1 0\nusemtl Glass\nf 1 2 3\nusemtl None\nf 3 2 1\n"
+	mtl := "newmtl Glass\nKd 0.0 0.5 1.0\nNs 96\nd 0.25\nmap_Kd -s 1 1 1 textures/glass.png\n"
+	ioutil.WriteFile(filepath.Join(dir, "box.obj"), []byte(obj), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "box.mtl"), []byte(mtl), 0644)
+
+	mesh, err := NewMeshFromObjPath(filepath.Join(dir, "box.obj"))
+	if err != nil {
+		t.Fatalf("Failed to load OBJ: %v", err)
+	}
+
+	if len(mesh.Materials) != 1 {
+		t.Fatalf("Expected 1 material, got %d", len(mesh.Materials))
+	}
+	glass := mesh.Materials[0]
+	if glass.Name != "Glass" || glass.Diffuse != (Vector3{0.0, 0.5, 1.0}) || glass.Opacity != 0.25 || glass.Shininess != 96 {
+		t.Errorf("Material was loaded wrong: %#v", glass)
+	}
+	if glass.DiffuseMap != filepath.Join(dir, "textures", "glass.png") {
+		t.Errorf("Texture path is wrong: %v", glass.DiffuseMap)
+	}
+
+	if mesh.FaceMaterials[0] != 0 || mesh.FaceMaterials[1] != -1 {
+		t.Errorf("Faces reference the wrong materials: %v", mesh.FaceMaterials)
+	}
+	if mesh.Colors[0] != 0xff007fff || mesh.Colors[1] != 0xffaaaaaa {
+		t.Errorf("Face colours don't match materials: %x", mesh.Colors)
+	}
+}
+
+func TestNewMaterialsFromMtlErrors(t *testing.T) {
+	_, err := NewMaterialsFromMtl(strings.NewReader("newmtl A\nKd 1 1 1\n\nKs 1 1\n"))
+
+	var objErr *ObjError
+	if !errors.As(err, &objErr) || objErr.Line != 4 {
+		t.Errorf("Expected an error on line 4, got %v", err)
+	}
+}
@@ -47,6 +47,13 @@ func NewTriangleMeshPlane(w, h int) TriangleMesh {
 				randomHeight(Point3{fx + 0, 0, fz + 0}),
 				randomHeight(Point3{fx + 1, 0, fz + 0}),
 			)
+			mesh.UVs = append(
+				mesh.UVs,
+				Vector2{0, 1},
+				Vector2{1, 1},
+				Vector2{0, 0},
+				Vector2{1, 0},
+			)
 			mesh.Triangles = append(
 				mesh.Triangles,
 				[3]int{idx + 0, idx + 1, idx + 2},
@@ -13,6 +13,7 @@ func NewLineMeshSphere() LineMesh {
 		Transform: NewTransform(),
 		Vertices:  []Point3{},
 		Lines:     []Line{},
+		Colors:    []uint32{},
 	}
 
 	for y := ySegments * -0.5; y <= ySegments*0.5; y++ {
@@ -28,11 +29,13 @@ func NewLineMeshSphere() LineMesh {
 				// Horizontal line
 				if x > xSegments*-0.5 {
 					mesh.Lines = append(mesh.Lines, Line{int(len(mesh.Vertices) - 2), int(len(mesh.Vertices) - 1)})
+					mesh.Colors = append(mesh.Colors, 0xffffffff)
 				}
 
 				// Vertical line
 				if y > ySegments*-0.5 {
 					mesh.Lines = append(mesh.Lines, Line{int(len(mesh.Vertices)) - int(xSegments) - 2, int(len(mesh.Vertices) - 1)})
+					mesh.Colors = append(mesh.Colors, 0xffffffff)
 				}
 			}
 		}
@@ -3,6 +3,7 @@ package mesh
 import (
 	"github.com/aquilax/go-perlin"
 	. "tri/geom"
+	. "tri/renderer"
 )
 
 func NewTerrainMesh(sx, sy, w, h int, scale float64) TriangleMesh {
@@ -64,6 +65,10 @@ func NewTerrainMesh(sx, sy, w, h int, scale float64) TriangleMesh {
 				randomHeight(Point3{fx + 0, 0, fz + 0}),
 				randomHeight(Point3{fx + 1, 0, fz + 0}),
 			)
+			// One texture repeat per square, lining up across chunks
+			for _, p := range mesh.Vertices[idx:] {
+				mesh.UVs = append(mesh.UVs, Vector2{float64(sx) + p.X(), float64(sy) + p.Z()})
+			}
 			mesh.Triangles = append(
 				mesh.Triangles,
 				[3]int{idx + 0, idx + 1, idx + 2},
@@ -83,5 +88,7 @@ func NewTerrainMesh(sx, sy, w, h int, scale float64) TriangleMesh {
 		}
 	}
 
+	mesh.SetShading(GouraudShading)
+
 	return mesh
 }
@@ -0,0 +1,94 @@
+package offscreen
+
+import (
+	"image"
+	"image/png"
+	"io"
+	"os"
+	. "tri/canvas"
+	. "tri/renderer"
+	. "tri/terminal"
+)
+
+// Renders without a terminal, for tests, thumbnails and batch jobs.
+// Works like a window, except frames are saved as images instead of presented.
+type Offscreen struct {
+	Canvas   Canvas
+	Renderer Renderer
+}
+
+// Creates an offscreen canvas, with a size measured in terminal cells
+func New(width, height int) *Offscreen {
+	o := &Offscreen{
+		Canvas: NewCanvas(width, height),
+		Renderer: Renderer{
+			Camera: NewCamera(PerspectiveProjection),
+		},
+	}
+	o.updateProjection()
+	o.Clear()
+	return o
+}
+
+func (o *Offscreen) Resize(width, height int) {
+	o.Canvas.Resize(width, height)
+	o.updateProjection()
+}
+
+// Switch between one or two pixels per cell
+func (o *Offscreen) SetPixelMode(mode PixelMode) {
+	o.Canvas.SetPixelMode(mode)
+}
+
+// Match the camera's aspect ratio to the shape of the canvas
+func (o *Offscreen) updateProjection() {
+	o.Renderer.Camera.SetViewport(o.Canvas.Width, o.Canvas.Height, DefaultCellAspect)
+}
+
+func (o *Offscreen) Clear() {
+	o.Canvas.Clear()
+}
+
+func (o *Offscreen) Draw(drawable Drawable) int {
+	o.Canvas.Lock()
+	defer o.Canvas.Unlock()
+	return o.Renderer.RenderDrawable(&o.Canvas, drawable)
+}
+
+func (o *Offscreen) DrawCanvas(x, y int, canvas *Canvas) {
+	o.Canvas.Lock()
+	defer o.Canvas.Unlock()
+	o.Canvas.DrawCanvas(x, y, canvas)
+}
+
+func (o *Offscreen) DrawLines(drawable LineDrawable) int {
+	o.Canvas.Lock()
+	defer o.Canvas.Unlock()
+	return o.Renderer.RenderLines(&o.Canvas, drawable)
+}
+
+// The current frame as an image
+func (o *Offscreen) Image(options ImageOptions) *image.RGBA {
+	o.Canvas.Lock()
+	defer o.Canvas.Unlock()
+	return o.Canvas.Image(options)
+}
+
+// Encodes the current frame as a PNG
+func (o *Offscreen) WritePNG(w io.Writer, options ImageOptions) error {
+	return png.Encode(w, o.Image(options))
+}
+
+// Saves the current frame to a PNG file
+func (o *Offscreen) SavePNG(path string, options ImageOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := o.WritePNG(f, options); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,42 @@
+package offscreen
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	. "tri/canvas"
+	. "tri/geom"
+	. "tri/mesh"
+)
+
+func TestOffscreenWritePNG(t *testing.T) {
+	o := New(40, 20)
+	o.Renderer.Camera.Transform.Translation = Vector3{0, 0, 6}
+
+	cube := NewTriangleMeshCube()
+	cube.Transform.Rotation = Vector3{0.5, 0.5, 0}
+	if count := o.Draw(&cube); count == 0 {
+		t.Fatalf("Cube wasn't drawn")
+	}
+
+	var buf bytes.Buffer
+	if err := o.WritePNG(&buf, ImageOptions{CellWidth: 2, CellHeight: 4}); err != nil {
+		t.Fatalf("Failed to write PNG: %v", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 80 || img.Bounds().Dy() != 80 {
+		t.Errorf("Image is the wrong size: %v", img.Bounds())
+	}
+
+	// The cube is in the middle, the corners are empty
+	if r, g, b, _ := img.At(40, 40).RGBA(); r+g+b == 0 {
+		t.Errorf("Cube is missing from the middle of the image")
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r+g+b != 0 {
+		t.Errorf("Something was drawn in the corner of the image")
+	}
+}
@@ -2,9 +2,73 @@ package renderer
 
 import . "tri/geom"
 
+// How a camera flattens the world onto the screen
+type ProjectionType uint8
+
+const (
+	// Projection is used as it is, for matrices built by hand
+	CustomProjection ProjectionType = iota
+	// Things further away look smaller
+	PerspectiveProjection
+	// Things are the same size however far away they are, like a technical drawing
+	OrthographicProjection
+	// Orthographic, looking down from a corner at the same angle to every axis
+	IsometricProjection
+)
+
 type Camera struct {
-	Projection Matrix4
-	Transform  Transform
+	// Built from the fields below by UpdateProjection, unless ProjectionType is CustomProjection
+	Projection     Matrix4
+	Transform      Transform
+	ProjectionType ProjectionType
+	// Vertical field of view in degrees, for perspective
+	FOV float64
+	// Height of the view in world units, for orthographic and isometric
+	Size float64
+	// Closest and furthest distances drawn
+	Near, Far float64
+	// Width of the view divided by its height, as it appears on screen
+	Aspect float64
+}
+
+func NewCamera(projectionType ProjectionType) Camera {
+	camera := Camera{
+		Transform:      NewTransform(),
+		ProjectionType: projectionType,
+		FOV:            45,
+		Size:           20,
+		Near:           0.1,
+		Far:            1000,
+		Aspect:         1,
+	}
+	camera.UpdateProjection()
+	return camera
+}
+
+// Builds the projection matrix from the camera's fields. Call it after changing them,
+// as drawing only reads the camera.
+func (c *Camera) UpdateProjection() {
+	switch c.ProjectionType {
+	case PerspectiveProjection:
+		c.Projection = NewMatrix4Perspective(c.Aspect, c.FOV, c.Near, c.Far)
+	case OrthographicProjection:
+		c.Projection = NewMatrix4Orthographic(c.Aspect, c.Size, c.Near, c.Far)
+	case IsometricProjection:
+		c.Projection = NewMatrix4Isometric(c.Aspect, c.Size, c.Near, c.Far)
+	}
+}
+
+// Switches to another kind of projection, and rebuilds the projection matrix
+func (c *Camera) SetProjectionType(projectionType ProjectionType) {
+	c.ProjectionType = projectionType
+	c.UpdateProjection()
+}
+
+// Matches the aspect ratio to a view measured in cells, which are cellAspect times as wide
+// as they are tall. Pixels aren't always square, so the size in pixels isn't enough.
+func (c *Camera) SetViewport(width, height int, cellAspect float64) {
+	c.Aspect = float64(width) / float64(height) * cellAspect
+	c.UpdateProjection()
 }
 
 func (c *Camera) View() Matrix4 {
@@ -35,3 +99,13 @@ func (c *Camera) Translate(x, y, z float64) {
 		newPosition.Z(),
 	}
 }
+
+// Turns the camera to face a point, with up at the top of the screen
+func (c *Camera) LookAt(target Point3, up Vector3) {
+	eye := c.Transform.Translation.ToPoint3()
+	// The view matrix turns the other way, and its rotation part is orthogonal
+	view := NewMatrix4LookAt(eye, target, up)
+	view[3], view[7], view[11] = 0, 0, 0
+	c.Transform.Rotation = Vector3{0, 0, 0}
+	c.Transform.Orientation = NewQuaternionFromMatrix4(view.Transpose())
+}
@@ -0,0 +1,59 @@
+package renderer
+
+import (
+	"testing"
+	. "tri/canvas"
+	. "tri/geom"
+)
+
+func TestCameraViewport(t *testing.T) {
+	camera := NewCamera(PerspectiveProjection)
+
+	// 80 by 20 cells, twice as tall as they're wide, are twice as wide as they're tall
+	camera.SetViewport(80, 20, 0.5)
+	if camera.Aspect != 2 {
+		t.Errorf("Expected an aspect ratio of 2, got %f", camera.Aspect)
+	}
+	if camera.Projection != NewMatrix4Perspective(2, 45, 0.1, 1000) {
+		t.Errorf("Expected the projection to be rebuilt")
+	}
+}
+
+func TestCameraProjections(t *testing.T) {
+	camera := NewCamera(OrthographicProjection)
+	camera.Size = 10
+	camera.UpdateProjection()
+	near, far := camera.Projection.TransformPoint3(Point3{5, 0, -1}), camera.Projection.TransformPoint3(Point3{5, 0, -100})
+	if near[0] != 1 || far[0] != 1 {
+		t.Errorf("Expected distance not to change the size of things, got %v and %v", near, far)
+	}
+
+	camera.SetProjectionType(IsometricProjection)
+	if camera.Projection != NewMatrix4Isometric(1, 10, 0.1, 1000) {
+		t.Errorf("Expected an isometric projection")
+	}
+
+	// Custom matrices are left alone
+	custom := NewMatrix4Scaling(2, 2, 2)
+	camera.SetProjectionType(CustomProjection)
+	camera.Projection = custom
+	camera.SetViewport(80, 20, 0.5)
+	if camera.Projection != custom {
+		t.Errorf("Expected a custom projection to be kept")
+	}
+}
+
+func TestDrawingLeavesCamera(t *testing.T) {
+	camera := NewCamera(PerspectiveProjection)
+	camera.Transform.Translation = Vector3{0, 0, 50}
+	canvas := NewCanvas(10, 10)
+	r := Renderer{Camera: camera}
+
+	// Changes to the fields wait for UpdateProjection
+	r.Camera.FOV = 90
+	before := r.Camera
+	r.newFrame(&canvas, nil)
+	if r.Camera != before {
+		t.Errorf("Expected drawing not to change the camera")
+	}
+}
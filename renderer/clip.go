@@ -0,0 +1,160 @@
+package renderer
+
+import . "tri/geom"
+
+// A vertex produced while clipping. Weights are the barycentric coordinates
+// of the vertex within the original triangle, so any per-vertex attribute can
+// be interpolated to match its new position.
+type clipVertex struct {
+	Point   Point3
+	Weights Vector3
+}
+
+// A piece of a triangle that survived clipping
+type clippedTriangle struct {
+	Shape   Triangle3
+	Weights [3]Vector3
+}
+
+// Interpolates per-vertex values of the original triangle (colours, normals,
+// etc.) onto the corners of the clipped triangle
+func (t clippedTriangle) Interpolate3(values [3]Vector3) [3]Vector3 {
+	result := [3]Vector3{}
+	for i, w := range t.Weights {
+		result[i] = values[0].Scale(w[0]).Add(values[1].Scale(w[1])).Add(values[2].Scale(w[2]))
+	}
+	return result
+}
+
+// Interpolates any number of per-vertex values. Every vertex must have the same number.
+func (t clippedTriangle) Interpolate(values [3][]float64) [3][]float64 {
+	result := [3][]float64{}
+	for i, w := range t.Weights {
+		result[i] = make([]float64, len(values[0]))
+		for j := range result[i] {
+			result[i][j] = values[0][j]*w[0] + values[1][j]*w[1] + values[2][j]*w[2]
+		}
+	}
+	return result
+}
+
+// Builds the six planes of the view frustum, in view space, from a projection matrix.
+// Plane normals point into the frustum. (Gribb & Hartmann's method)
+func frustumPlanes(proj Matrix4) []Plane3 {
+	rows := proj.Rows()
+	add := func(a, b Vector4) Vector4 {
+		return Vector4{a[0] + b[0], a[1] + b[1], a[2] + b[2], a[3] + b[3]}
+	}
+	sub := func(a, b Vector4) Vector4 {
+		return add(a, b.Scale(-1))
+	}
+
+	return []Plane3{
+		planeFromVector4(add(rows[3], rows[2])), // Near
+		planeFromVector4(sub(rows[3], rows[2])), // Far
+		planeFromVector4(add(rows[3], rows[0])), // Left
+		planeFromVector4(sub(rows[3], rows[0])), // Right
+		planeFromVector4(add(rows[3], rows[1])), // Bottom
+		planeFromVector4(sub(rows[3], rows[1])), // Top
+	}
+}
+
+// Converts a plane in the form ax + by + cz + d = 0 into a Plane3
+func planeFromVector4(v Vector4) Plane3 {
+	normal := Vector3{v[0], v[1], v[2]}
+	scale := -v[3] / normal.Dot(normal)
+	return Plane3{
+		Point:  normal.Scale(scale).ToPoint3(),
+		Normal: normal,
+	}
+}
+
+// Clips a triangle against a plane, keeping the part on the side the plane's normal faces.
+// Returns zero, one or two triangles.
+func clipTriangle(plane Plane3, tri Triangle3) []clippedTriangle {
+	return clipTriangleToPlanes([]Plane3{plane}, tri)
+}
+
+// Clips a triangle against several planes in turn
+func clipTriangleToPlanes(planes []Plane3, tri Triangle3) []clippedTriangle {
+	polygon := []clipVertex{
+		{tri[0], Vector3{1, 0, 0}},
+		{tri[1], Vector3{0, 1, 0}},
+		{tri[2], Vector3{0, 0, 1}},
+	}
+
+	for _, plane := range planes {
+		polygon = clipPolygon(plane, polygon)
+		if len(polygon) < 3 {
+			return []clippedTriangle{}
+		}
+	}
+
+	// Triangulate the resulting convex polygon as a fan
+	triangles := make([]clippedTriangle, 0, len(polygon)-2)
+	for i := 1; i < len(polygon)-1; i++ {
+		a, b, c := polygon[0], polygon[i], polygon[i+1]
+		triangles = append(triangles, clippedTriangle{
+			Shape:   Triangle3{a.Point, b.Point, c.Point},
+			Weights: [3]Vector3{a.Weights, b.Weights, c.Weights},
+		})
+	}
+
+	return triangles
+}
+
+// Sutherland–Hodgman clipping of a convex polygon against a single plane
+func clipPolygon(plane Plane3, polygon []clipVertex) []clipVertex {
+	if len(polygon) == 0 {
+		return polygon
+	}
+
+	result := make([]clipVertex, 0, len(polygon)+1)
+	prev := polygon[len(polygon)-1]
+	prevDist := prev.Point.DistanceToPlane3(plane)
+
+	for _, cur := range polygon {
+		curDist := cur.Point.DistanceToPlane3(plane)
+
+		// Edge crosses the plane
+		if (prevDist < 0 && curDist > 0) || (prevDist > 0 && curDist < 0) {
+			t := prevDist / (prevDist - curDist)
+			result = append(result, clipVertex{
+				Point:   prev.Point.ToVector3().Add(cur.Point.ToVector3().Sub(prev.Point.ToVector3()).Scale(t)).ToPoint3(),
+				Weights: prev.Weights.Add(cur.Weights.Sub(prev.Weights).Scale(t)),
+			})
+		}
+
+		if curDist >= 0 {
+			result = append(result, cur)
+		}
+
+		prev, prevDist = cur, curDist
+	}
+
+	return result
+}
+
+// Clips a line against several planes in turn. Returns false if nothing is left.
+func clipLine(planes []Plane3, line Line3) (Line3, bool) {
+	for _, plane := range planes {
+		startDist := line[0].DistanceToPlane3(plane)
+		endDist := line[1].DistanceToPlane3(plane)
+
+		if startDist < 0 && endDist < 0 {
+			return line, false
+		}
+		if startDist < 0 || endDist < 0 {
+			t := startDist / (startDist - endDist)
+			start, end := line[0].ToVector3(), line[1].ToVector3()
+			intersect := start.Add(end.Sub(start).Scale(t)).ToPoint3()
+			if startDist < 0 {
+				line[0] = intersect
+			} else {
+				line[1] = intersect
+			}
+		}
+	}
+
+	return line, true
+}
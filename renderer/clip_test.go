@@ -0,0 +1,126 @@
+package renderer
+
+import (
+	"math"
+	"testing"
+	. "tri/geom"
+)
+
+func assertValuesEqual(t *testing.T, actual []float64, expected []float64) {
+	if len(actual) != len(expected) {
+		t.Errorf("Lens differ: %#v != %#v", actual, expected)
+		return
+	}
+	for i, n := range actual {
+		if math.Abs(n-expected[i]) > 0.001 {
+			t.Errorf("Value at %d is wrong  %#v != %#v", i, actual, expected)
+			break
+		}
+	}
+}
+
+func TestClipTriangle(t *testing.T) {
+	// Keeps everything in front of z = -1
+	plane := Plane3{
+		Point:  Point3{0, 0, -1},
+		Normal: Vector3{0, 0, -1},
+	}
+	// Red, green and blue corners
+	colors := [3]Vector3{
+		Vector3{1, 0, 0},
+		Vector3{0, 1, 0},
+		Vector3{0, 0, 1},
+	}
+
+	tests := []struct {
+		name     string
+		triangle Triangle3
+		expected []Triangle3
+		colors   [][3]Vector3
+	}{
+		{
+			name:     "0 inside",
+			triangle: Triangle3{Point3{0, 0, 1}, Point3{2, 0, 1}, Point3{0, 2, 0}},
+			expected: []Triangle3{},
+			colors:   [][3]Vector3{},
+		},
+		{
+			name:     "1 inside",
+			triangle: Triangle3{Point3{0, 0, -3}, Point3{2, 0, 1}, Point3{0, 2, 1}},
+			expected: []Triangle3{
+				Triangle3{Point3{0, 1, -1}, Point3{0, 0, -3}, Point3{1, 0, -1}},
+			},
+			colors: [][3]Vector3{
+				[3]Vector3{Vector3{0.5, 0, 0.5}, Vector3{1, 0, 0}, Vector3{0.5, 0.5, 0}},
+			},
+		},
+		{
+			name:     "2 inside",
+			triangle: Triangle3{Point3{0, 0, -3}, Point3{2, 0, -3}, Point3{0, 2, 1}},
+			expected: []Triangle3{
+				Triangle3{Point3{0, 1, -1}, Point3{0, 0, -3}, Point3{2, 0, -3}},
+				Triangle3{Point3{0, 1, -1}, Point3{2, 0, -3}, Point3{1, 1, -1}},
+			},
+			colors: [][3]Vector3{
+				[3]Vector3{Vector3{0.5, 0, 0.5}, Vector3{1, 0, 0}, Vector3{0, 1, 0}},
+				[3]Vector3{Vector3{0.5, 0, 0.5}, Vector3{0, 1, 0}, Vector3{0, 0.5, 0.5}},
+			},
+		},
+		{
+			name:     "3 inside",
+			triangle: Triangle3{Point3{0, 0, -3}, Point3{2, 0, -3}, Point3{0, 2, -2}},
+			expected: []Triangle3{
+				Triangle3{Point3{0, 0, -3}, Point3{2, 0, -3}, Point3{0, 2, -2}},
+			},
+			colors: [][3]Vector3{colors},
+		},
+		{
+			name:     "Touching the plane",
+			triangle: Triangle3{Point3{0, 0, -1}, Point3{2, 0, -1}, Point3{0, 2, 1}},
+			expected: []Triangle3{},
+			colors:   [][3]Vector3{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := clipTriangle(plane, test.triangle)
+
+			if len(result) != len(test.expected) {
+				t.Fatalf("Expected %d triangles, got %d: %#v", len(test.expected), len(result), result)
+			}
+			for i, tri := range result {
+				for j := range tri.Shape {
+					assertValuesEqual(t, tri.Shape[j][:], test.expected[i][j][:])
+				}
+				interpolated := tri.Interpolate3(colors)
+				for j := range interpolated {
+					assertValuesEqual(t, interpolated[j][:], test.colors[i][j][:])
+				}
+			}
+		})
+	}
+}
+
+func TestClipTriangleToFrustum(t *testing.T) {
+	proj := NewMatrix4Perspective(1.0, 90.0, 0.1, 100.0)
+	planes := frustumPlanes(proj)
+
+	// Straddles the near plane and pokes out of the right hand side
+	tri := Triangle3{Point3{0, 0, -10}, Point3{50, 0, -10}, Point3{0, 0, 10}}
+	result := clipTriangleToPlanes(planes, tri)
+
+	if len(result) == 0 {
+		t.Fatalf("Expected triangle to be partially visible")
+	}
+	for _, clipped := range result {
+		for _, p := range clipped.Shape {
+			ndc := proj.TransformPoint3(p)
+			for axis, v := range ndc {
+				if v < -1.001 || v > 1.001 {
+					t.Errorf("Point %v is outside the frustum on axis %d (%v)", p, axis, ndc)
+				}
+			}
+		}
+	}
+}
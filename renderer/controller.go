@@ -0,0 +1,132 @@
+package renderer
+
+import (
+	"math"
+	. "tri/geom"
+)
+
+// Up at the top of the screen, since -Y is up in the world
+var worldUp = Vector3{0, -1, 0}
+
+// Furthest a camera can look up or down, just short of straight up so it doesn't flip over
+const maxPitch = math.Pi/2 - 0.01
+
+// Moves a camera to follow input. Call Apply after changing the controller, to move the camera.
+type CameraController interface {
+	Apply(camera *Camera)
+}
+
+// Turning by yaw around the world's up, then pitch up or down. Angles are in radians, the
+// same way round as Transform.Rotation: positive yaw turns left and positive pitch looks down.
+func yawPitch(yaw, pitch float64) Quaternion {
+	return NewQuaternionFromEuler(0, yaw, 0).Multiply(NewQuaternionFromEuler(pitch, 0, 0))
+}
+
+func clampPitch(pitch float64) float64 {
+	return math.Max(-maxPitch, math.Min(maxPitch, pitch))
+}
+
+func setOrientation(camera *Camera, position Vector3, orientation Quaternion) {
+	camera.Transform.Translation = position
+	camera.Transform.Rotation = Vector3{0, 0, 0}
+	camera.Transform.Orientation = orientation
+}
+
+// Flies freely, turning with yaw and pitch and moving the way it's looking
+type FlyController struct {
+	Position   Vector3
+	Yaw, Pitch float64
+}
+
+func NewFlyController(position Vector3, yaw, pitch float64) *FlyController {
+	return &FlyController{Position: position, Yaw: yaw, Pitch: clampPitch(pitch)}
+}
+
+func (c *FlyController) Turn(yaw, pitch float64) {
+	c.Yaw += yaw
+	c.Pitch = clampPitch(c.Pitch + pitch)
+}
+
+// Moves relative to the camera: +X right, +Y down and -Z forward
+func (c *FlyController) Move(direction Vector3) {
+	c.Position = c.Position.Add(yawPitch(c.Yaw, c.Pitch).RotateVector3(direction))
+}
+
+func (c *FlyController) Apply(camera *Camera) {
+	setOrientation(camera, c.Position, yawPitch(c.Yaw, c.Pitch))
+}
+
+// Circles around a target, always looking at it
+type OrbitController struct {
+	Target     Vector3
+	Distance   float64
+	Yaw, Pitch float64
+	// Closest and furthest Zoom goes
+	MinDistance, MaxDistance float64
+}
+
+func NewOrbitController(target Vector3, distance float64) *OrbitController {
+	return &OrbitController{
+		Target:      target,
+		Distance:    distance,
+		MinDistance: 1,
+		MaxDistance: 500,
+	}
+}
+
+func (c *OrbitController) Rotate(yaw, pitch float64) {
+	c.Yaw += yaw
+	c.Pitch = clampPitch(c.Pitch + pitch)
+}
+
+// Multiplies the distance from the target, so less than 1 moves closer
+func (c *OrbitController) Zoom(scale float64) {
+	c.Distance = math.Max(c.MinDistance, math.Min(c.MaxDistance, c.Distance*scale))
+}
+
+// Moves the target relative to the camera: +X right, +Y down and -Z forward
+func (c *OrbitController) Pan(direction Vector3) {
+	c.Target = c.Target.Add(yawPitch(c.Yaw, c.Pitch).RotateVector3(direction))
+}
+
+func (c *OrbitController) Apply(camera *Camera) {
+	orientation := yawPitch(c.Yaw, c.Pitch)
+	position := c.Target.Add(orientation.RotateVector3(Vector3{0, 0, c.Distance}))
+	setOrientation(camera, position, orientation)
+}
+
+// Trails behind a moving target, catching up smoothly
+type FollowController struct {
+	// Where the camera sits, relative to the target and the way it's heading
+	Offset Vector3
+	// How quickly the camera catches up, as the fraction of the gap left after a
+	// second is e^-Stiffness. 0 keeps it exactly at the offset.
+	Stiffness float64
+
+	target   Vector3
+	position Vector3
+	started  bool
+}
+
+func NewFollowController(offset Vector3, stiffness float64) *FollowController {
+	return &FollowController{Offset: offset, Stiffness: stiffness}
+}
+
+// Moves towards the target, which is turned by heading around the world's up. dt is the time
+// since the last call, in seconds. The first call jumps straight there.
+func (c *FollowController) Follow(target Vector3, heading, dt float64) {
+	c.target = target
+	wanted := target.Add(NewQuaternionFromEuler(0, heading, 0).RotateVector3(c.Offset))
+	if !c.started || c.Stiffness == 0 {
+		c.position = wanted
+		c.started = true
+		return
+	}
+	catchUp := 1 - math.Exp(-c.Stiffness*dt)
+	c.position = c.position.Add(wanted.Sub(c.position).Scale(catchUp))
+}
+
+func (c *FollowController) Apply(camera *Camera) {
+	camera.Transform.Translation = c.position
+	camera.LookAt(c.target.ToPoint3(), worldUp)
+}
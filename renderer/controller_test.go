@@ -0,0 +1,86 @@
+package renderer
+
+import (
+	"math"
+	"testing"
+	. "tri/geom"
+)
+
+func assertNear(t *testing.T, actual, expected Point3) {
+	t.Helper()
+	for i := range actual {
+		if math.Abs(actual[i]-expected[i]) > 0.001 {
+			t.Errorf("Expected %v, got %v", expected, actual)
+			return
+		}
+	}
+}
+
+func newTestCamera() Camera {
+	return Camera{Transform: NewTransform()}
+}
+
+func TestFlyController(t *testing.T) {
+	camera := newTestCamera()
+	fly := NewFlyController(Vector3{0, -2, 10}, 0, 0)
+
+	// Turning left and moving forward goes towards -X
+	fly.Turn(math.Pi/2, 0)
+	fly.Move(Vector3{0, 0, -5})
+	fly.Apply(&camera)
+	assertNear(t, camera.Transform.Translation.ToPoint3(), Point3{-5, -2, 10})
+	assertNear(t, camera.View().TransformPoint3(Point3{-15, -2, 10}), Point3{0, 0, -10})
+
+	// Pitch stops short of looking straight down
+	fly.Turn(0, 10)
+	if fly.Pitch >= math.Pi/2 {
+		t.Errorf("Expected pitch to be clamped, got %f", fly.Pitch)
+	}
+}
+
+func TestOrbitController(t *testing.T) {
+	camera := newTestCamera()
+	orbit := NewOrbitController(Vector3{1, 2, 3}, 10)
+	orbit.Rotate(0.7, 0.4)
+	orbit.Apply(&camera)
+
+	// Wherever it goes, the target stays straight ahead
+	assertNear(t, camera.View().TransformPoint3(Point3{1, 2, 3}), Point3{0, 0, -10})
+	// Looking down at it from above
+	if camera.Transform.Translation[1] >= 2 {
+		t.Errorf("Expected the camera to be above the target, got %v", camera.Transform.Translation)
+	}
+
+	orbit.Zoom(0.5)
+	orbit.Apply(&camera)
+	assertNear(t, camera.View().TransformPoint3(Point3{1, 2, 3}), Point3{0, 0, -5})
+	orbit.Zoom(0.001)
+	if orbit.Distance != orbit.MinDistance {
+		t.Errorf("Expected zooming to stop at %f, got %f", orbit.MinDistance, orbit.Distance)
+	}
+
+	// Panning moves the target along with the camera
+	orbit.Pan(Vector3{2, 0, 0})
+	orbit.Apply(&camera)
+	assertNear(t, camera.View().TransformPoint3(orbit.Target.ToPoint3()), Point3{0, 0, -orbit.Distance})
+}
+
+func TestFollowController(t *testing.T) {
+	camera := newTestCamera()
+	follow := NewFollowController(Vector3{0, -2, 6}, 5)
+
+	follow.Follow(Vector3{0, 0, 0}, 0, 0.1)
+	follow.Apply(&camera)
+	assertNear(t, camera.Transform.Translation.ToPoint3(), Point3{0, -2, 6})
+
+	// Turned around, it only catches up part of the way
+	follow.Follow(Vector3{0, 0, 0}, math.Pi, 0.1)
+	follow.Apply(&camera)
+	position := camera.Transform.Translation
+	caughtUp := 1 - math.Exp(-0.5)
+	assertNear(t, position.ToPoint3(), Point3{0, -2, 6 - 12*caughtUp})
+
+	// Always looking at the target
+	distance := position.Magnitude()
+	assertNear(t, camera.View().TransformPoint3(Point3{0, 0, 0}), Point3{0, 0, -distance})
+}
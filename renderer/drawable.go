@@ -1,12 +1,43 @@
 package renderer
 
-import "tri/geom"
+import (
+	"tri/geom"
+	"tri/texture"
+)
+
+// How lighting is applied across a polygon
+type Shading uint8
+
+const (
+	// One colour for the whole polygon, from its face normal
+	FlatShading Shading = iota
+	// Light each vertex and blend the colours between them
+	GouraudShading
+	// Blend the vertex normals and light every pixel
+	PhongShading
+)
 
 type Polygon struct {
-	Shape geom.Triangle3
-	Color uint32
+	Shape    geom.Triangle3
+	Color    uint32
+	Material *Material
+	// Vertex normals in world space. Left empty to use the face normal.
+	Normals [3]geom.Vector3
+	Shading Shading
+	// Replaces the colour when set, mapped with the UVs
+	Texture *texture.Texture
+	UVs     [3]geom.Vector2
 }
 
 type Drawable interface {
 	DrawTriangles(ch chan<- Polygon)
 }
+
+type LineSegment struct {
+	Shape geom.Line3
+	Color uint32
+}
+
+type LineDrawable interface {
+	DrawLines(ch chan<- LineSegment)
+}
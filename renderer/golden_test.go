@@ -0,0 +1,89 @@
+package renderer_test
+
+import (
+	"flag"
+	"testing"
+	. "tri/canvas"
+	"tri/canvas/canvastest"
+	. "tri/geom"
+	. "tri/mesh"
+	. "tri/renderer"
+	. "tri/scene"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with what's drawn now")
+
+// A canvas and renderer looking at the origin from a fixed position
+func newGoldenScene(width, height int, translation, rotation Vector3) (*Canvas, Renderer) {
+	canvas := NewCanvas(width, height)
+	canvas.ClearWithCell(Cell{Fg: 0xffffffff, Bg: 0xff101020, Depth: 1000000, Sprite: ' '})
+
+	renderer := Renderer{
+		Camera: Camera{
+			Projection: NewMatrix4Perspective(float64(width)/float64(height), 45, 0.1, 1000.0),
+			Transform: Transform{
+				Translation: translation,
+				Rotation:    rotation,
+				Scaling:     Vector3{1, 1, 1},
+			},
+		},
+	}
+	return &canvas, renderer
+}
+
+func TestRenderCubeGolden(t *testing.T) {
+	canvas, renderer := newGoldenScene(40, 20, Vector3{0, 0, 6}, Vector3{})
+
+	cube := NewTriangleMeshCube()
+	cube.Transform.Rotation = Vector3{0.6, 0.8, 0}
+	renderer.RenderDrawable(canvas, &cube)
+
+	canvastest.AssertGolden(t, "cube", canvas, *update)
+}
+
+func TestRenderSphereGolden(t *testing.T) {
+	canvas, renderer := newGoldenScene(40, 20, Vector3{0, 0, 4}, Vector3{})
+
+	sphere := NewLineMeshSphere()
+	sphere.Transform.Rotation = Vector3{0.3, 0.2, 0}
+	renderer.RenderLines(canvas, &sphere)
+
+	canvastest.AssertGolden(t, "sphere", canvas, *update)
+}
+
+func TestRenderTerrainGolden(t *testing.T) {
+	canvas, renderer := newGoldenScene(48, 20, Vector3{0, -6, 10}, Vector3{0.5, 0, 0})
+
+	terrain := NewTerrainMesh(0, 0, 8, 8, 0.1)
+	terrain.Transform.Scaling = Vector3{1, 5, 1}
+	renderer.RenderDrawable(canvas, &terrain)
+
+	canvastest.AssertGolden(t, "terrain", canvas, *update)
+}
+
+func TestRenderSuzanneGolden(t *testing.T) {
+	canvas, renderer := newGoldenScene(48, 24, Vector3{0, 0, 4}, Vector3{})
+	canvas.SetPixelMode(HalfBlockPixels)
+	canvas.ClearWithCell(Cell{Fg: 0xffffffff, Bg: 0xff101020, Depth: 1000000, Sprite: ' '})
+	renderer.Camera.Projection = NewMatrix4Perspective(float64(canvas.PixelWidth())/float64(canvas.PixelHeight()), 45, 0.1, 1000.0)
+
+	suzanne, err := NewMeshFromObjPath("../assets/suzanne.obj")
+	if err != nil {
+		t.Fatalf("Failed to load Suzanne: %v", err)
+	}
+	suzanne.SetShading(PhongShading)
+	// Blender's +Y up is our -Y up
+	suzanne.Transform.Rotation = Vector3{0, 0.4, 3.14159}
+
+	// Lit from the front left by a shiny white light
+	suzanne.Materials = []Material{NewMaterial("Shiny")}
+	suzanne.Materials[0].Specular = Vector3{0.5, 0.5, 0.5}
+	suzanne.Materials[0].Shininess = 20
+	suzanne.FaceMaterials = make([]int, len(suzanne.Triangles))
+	scene := NewSceneWith([]TriangleMesh{suzanne})
+	scene.AddLight(NewAmbientLight(Vector3{1, 1, 1}, 0.1))
+	scene.AddLight(NewDirectionalLight(Vector3{0.5, 0.3, -1}, Vector3{1, 1, 1}, 0.9))
+	renderer.RenderDrawable(canvas, scene.Snapshot())
+
+	canvastest.AssertGolden(t, "suzanne", canvas, *update)
+}
@@ -0,0 +1,147 @@
+package renderer
+
+import (
+	"math"
+	. "tri/geom"
+)
+
+type LightType uint8
+
+const (
+	// Lights everything equally, from every direction
+	AmbientLight LightType = iota
+	// Infinitely far away, like the sun
+	DirectionalLight
+	// Shines in every direction from a position, fading with distance
+	PointLight
+	// A point light limited to a cone
+	SpotLight
+)
+
+type Light struct {
+	Type      LightType
+	Color     Vector3
+	Intensity float64
+	// Where point and spot lights are
+	Position Point3
+	// Which way directional and spot lights shine
+	Direction Vector3
+	// Fall off with distance, for point and spot lights:
+	//   1 / (Constant + Linear*distance + Quadratic*distance²)
+	Constant  float64
+	Linear    float64
+	Quadratic float64
+	// Spot light cone, in radians from its centre. Fades out between the inner and outer angles.
+	InnerAngle float64
+	OuterAngle float64
+}
+
+// Drawables that bring their own lights
+type Lit interface {
+	Lighting() []Light
+}
+
+func NewAmbientLight(color Vector3, intensity float64) Light {
+	return Light{
+		Type:      AmbientLight,
+		Color:     color,
+		Intensity: intensity,
+	}
+}
+
+func NewDirectionalLight(direction, color Vector3, intensity float64) Light {
+	return Light{
+		Type:      DirectionalLight,
+		Color:     color,
+		Intensity: intensity,
+		Direction: direction.Normalize(),
+	}
+}
+
+// A point light that fades to almost nothing by the time it reaches radius
+func NewPointLight(position Point3, color Vector3, intensity, radius float64) Light {
+	return Light{
+		Type:      PointLight,
+		Color:     color,
+		Intensity: intensity,
+		Position:  position,
+		Constant:  1.0,
+		Linear:    4.5 / radius,
+		Quadratic: 75.0 / (radius * radius),
+	}
+}
+
+// A spot light with a cone angle in radians. The edge of the cone is softened by 10%.
+func NewSpotLight(position Point3, direction Vector3, angle float64, color Vector3, intensity, radius float64) Light {
+	light := NewPointLight(position, color, intensity, radius)
+	light.Type = SpotLight
+	light.Direction = direction.Normalize()
+	light.InnerAngle = angle * 0.9
+	light.OuterAngle = angle
+	return light
+}
+
+// The lighting used when a scene doesn't have any lights of its own
+func DefaultLights() []Light {
+	return []Light{
+		NewAmbientLight(Vector3{1, 1, 1}, 0.1),
+		NewDirectionalLight(Vector3{-0.4, 0.7, 0.3}, Vector3{1, 1, 1}, 1.0),
+	}
+}
+
+// Light arriving at a point on a surface. Returns the diffuse and specular
+// colours, ready to be multiplied by the surface's own colours.
+func (l *Light) Illuminate(point Point3, normal, toCamera Vector3, shininess float64) (Vector3, Vector3) {
+	color := l.Color.Scale(l.Intensity)
+	if l.Type == AmbientLight {
+		return color, Vector3{}
+	}
+
+	var toLight Vector3
+	if l.Type == DirectionalLight {
+		toLight = l.Direction.Scale(-1)
+	} else {
+		offset := l.Position.ToVector3().Sub(point.ToVector3())
+		distance := offset.Magnitude()
+		if distance == 0 {
+			return color, Vector3{}
+		}
+		toLight = offset.Scale(1 / distance)
+
+		attenuation := l.Constant + l.Linear*distance + l.Quadratic*distance*distance
+		if attenuation > 0 {
+			color = color.Scale(1 / attenuation)
+		}
+	}
+
+	if l.Type == SpotLight {
+		cos := toLight.Scale(-1).Dot(l.Direction)
+		cosInner, cosOuter := math.Cos(l.InnerAngle), math.Cos(l.OuterAngle)
+		if cos < cosOuter {
+			return Vector3{}, Vector3{}
+		}
+		if cos < cosInner {
+			color = color.Scale((cos - cosOuter) / (cosInner - cosOuter))
+		}
+	}
+
+	lambert := normal.Dot(toLight)
+	if lambert <= 0 {
+		return Vector3{}, Vector3{}
+	}
+	diffuse := color.Scale(lambert)
+
+	// Blinn-Phong highlight
+	specular := Vector3{}
+	if shininess > 0 {
+		halfway := toLight.Add(toCamera.Normalize())
+		if halfway.Magnitude() > 0 {
+			angle := normal.Dot(halfway.Normalize())
+			if angle > 0 {
+				specular = color.Scale(math.Pow(angle, shininess))
+			}
+		}
+	}
+
+	return diffuse, specular
+}
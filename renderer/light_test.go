@@ -0,0 +1,64 @@
+package renderer
+
+import (
+	"math"
+	"testing"
+	. "tri/geom"
+)
+
+func TestLightIlluminate(t *testing.T) {
+	white := Vector3{1, 1, 1}
+	up := Vector3{0, -1, 0}
+	floor := Point3{0, 0, 0}
+	tests := []struct {
+		name     string
+		light    Light
+		expected float64
+	}{
+		{"ambient", NewAmbientLight(white, 0.25), 0.25},
+		{"directional overhead", NewDirectionalLight(Vector3{0, 1, 0}, white, 1), 1},
+		{"directional at an angle", NewDirectionalLight(Vector3{1, 1, 0}, white, 1), math.Sqrt(0.5)},
+		{"directional from below", NewDirectionalLight(Vector3{0, -1, 0}, white, 1), 0},
+		{"point", Light{Type: PointLight, Color: white, Intensity: 1, Position: Point3{0, -2, 0}, Constant: 1, Quadratic: 1}, 0.2},
+		{"spot inside cone", NewSpotLight(Point3{0, -2, 0}, Vector3{0, 1, 0}, 0.5, white, 1, 1e9), 1},
+		{"spot outside cone", NewSpotLight(Point3{0, -2, 0}, Vector3{1, 0, 0}, 0.5, white, 1, 1e9), 0},
+	}
+
+	for _, test := range tests {
+		diffuse, _ := test.light.Illuminate(floor, up, up, 0)
+		for _, channel := range diffuse {
+			if math.Abs(channel-test.expected) > 0.0001 {
+				t.Errorf("%s: expected %v, got %v", test.name, test.expected, diffuse)
+				break
+			}
+		}
+	}
+}
+
+func TestLightSpecular(t *testing.T) {
+	light := NewDirectionalLight(Vector3{0, 1, 0}, Vector3{1, 1, 1}, 1)
+	up := Vector3{0, -1, 0}
+
+	_, facing := light.Illuminate(Point3{}, up, up, 32)
+	_, glancing := light.Illuminate(Point3{}, up, Vector3{1, -0.2, 0}, 32)
+	if facing[0] < 0.99 {
+		t.Errorf("Expected a full highlight when reflecting into the camera, got %v", facing)
+	}
+	if glancing[0] >= facing[0] {
+		t.Errorf("Highlight should fade away from the reflection, got %v", glancing)
+	}
+}
+
+func TestMaterialAmbient(t *testing.T) {
+	f := frame{lights: []Light{
+		NewAmbientLight(Vector3{1, 1, 1}, 0.5),
+		NewDirectionalLight(Vector3{0, 1, 0}, Vector3{1, 1, 1}, 1),
+	}}
+	surf := surface{ambient: Vector3{1, 0.5, 0}}
+
+	// Only the ambient light is changed by the material
+	diffuse, _ := f.illuminate(&surf, Point3{0, 0, 0}, Vector3{0, -1, 0})
+	if diffuse != (Vector3{1.5, 1.25, 1}) {
+		t.Errorf("Expected {1.5 1.25 1}, got %v", diffuse)
+	}
+}
@@ -0,0 +1,31 @@
+package renderer
+
+import (
+	. "tri/geom"
+	. "tri/texture"
+)
+
+// How a surface reacts to light. Usually loaded from an MTL file.
+type Material struct {
+	Name      string
+	Ambient   Vector3
+	Diffuse   Vector3
+	Specular  Vector3
+	Shininess float64
+	// 0.0 is invisible, 1.0 is solid
+	Opacity float64
+	// Path to an image to use for the diffuse colour
+	DiffuseMap string
+	// The image at DiffuseMap, once loaded
+	DiffuseTexture *Texture
+}
+
+func NewMaterial(name string) Material {
+	return Material{
+		Name:     name,
+		Ambient:  Vector3{1, 1, 1},
+		Diffuse:  Vector3{0.8, 0.8, 0.8},
+		Specular: Vector3{0, 0, 0},
+		Opacity:  1.0,
+	}
+}
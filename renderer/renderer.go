@@ -3,40 +3,103 @@ package renderer
 import (
 	. "tri/canvas"
 	. "tri/geom"
+	. "tri/texture"
 )
 
 type Renderer struct {
 	Camera Camera
 }
 
-func clipTriangle(plane Plane3, tri Triangle3) []Triangle3 {
-	inside := []Point3{}
-	outside := []Point3{}
+// Moves a point from view space to the screen, keeping W for perspective correction
+func projectVertex(proj Matrix4, point Point3, attributes []float64) Vertex {
+	clip := proj.MultiplyVector4(Vector4{point[0], point[1], point[2], 1})
+	return Vertex{
+		Position:   Point3{clip[0] / clip[3], clip[1] / clip[3], clip[2] / clip[3]},
+		W:          clip[3],
+		Attributes: attributes,
+	}
+}
 
-	for _, point := range tri {
-		if point.DistanceToPlane3(plane) <= 0 {
-			inside = append(inside, point)
-			// TODO actually clip the triangle. For now just remove it
-			return []Triangle3{}
-		} else {
-			outside = append(outside, point)
+// State shared by every polygon drawn in a frame
+type frame struct {
+	canvas *Canvas
+	view   Matrix4
+	proj   Matrix4
+	planes []Plane3
+	lights []Light
+	// Camera position in world space, as a homogeneous point
+	eye Vector4
+}
+
+func (r *Renderer) newFrame(canvas *Canvas, lights []Light) frame {
+	camera := &r.Camera
+	view := camera.View()
+
+	// The point the projection converges on, carried back into world space
+	invProj, _ := camera.Projection.Inverse()
+	invView := camera.Transform.Matrix()
+	eye := invView.MultiplyVector4(invProj.MultiplyVector4(Vector4{0, 0, -1, 0}))
+
+	return frame{
+		canvas: canvas,
+		view:   view,
+		proj:   camera.Projection,
+		planes: frustumPlanes(camera.Projection),
+		lights: lights,
+		eye:    eye,
+	}
+}
+
+// Direction from a point towards the camera
+func (f *frame) toCamera(point Point3) Vector3 {
+	eye := Vector3{f.eye[0], f.eye[1], f.eye[2]}
+	return eye.Sub(point.ToVector3().Scale(f.eye[3]))
+}
+
+// Light arriving at a point from every light in the frame, as diffuse and specular colours
+func (f *frame) illuminate(surface *surface, point Point3, normal Vector3) (Vector3, Vector3) {
+	toCamera := f.toCamera(point)
+	diffuse, specular := Vector3{}, Vector3{}
+	for i := range f.lights {
+		d, s := f.lights[i].Illuminate(point, normal, toCamera, surface.shininess)
+		if f.lights[i].Type == AmbientLight {
+			d = d.Multiply(surface.ambient)
 		}
+		diffuse = diffuse.Add(d)
+		specular = specular.Add(s)
 	}
+	return diffuse, specular
+}
+
+// How a polygon reflects light
+type surface struct {
+	// How much ambient light is reflected, on top of the diffuse colour
+	ambient   Vector3
+	diffuse   Vector3
+	specular  Vector3
+	shininess float64
+	// Replaces the diffuse colour when set
+	texture *Texture
+}
 
-	return []Triangle3{tri}
+// Final colour of a surface with a base colour, lit by some amount of light
+func (s *surface) shade(albedo, diffuse, specular Vector3) Vector3 {
+	return albedo.Multiply(diffuse).Add(s.specular.Multiply(specular))
 }
 
+// Draws every polygon of a drawable. If it has lights of its own they're used,
+// otherwise it's lit by DefaultLights.
 func (r *Renderer) RenderDrawable(canvas *Canvas, mesh Drawable) int {
 	count := 0
-	camera := &r.Camera
-	lightDir := Vector3{0.4, -0.7, -0.3}.Normalize()
 
-	proj := camera.Projection
-	view := camera.View()
-	nearPlane := Plane3{
-		Point:  Point3{0.0, 0.0, -0.1},
-		Normal: Vector3{0.0, 0.0, -1.0},
+	var lights []Light
+	if lit, ok := mesh.(Lit); ok {
+		lights = lit.Lighting()
 	}
+	if lights == nil {
+		lights = DefaultLights()
+	}
+	f := r.newFrame(canvas, lights)
 
 	ch := make(chan Polygon, 100)
 	go func() {
@@ -50,41 +113,156 @@ func (r *Renderer) RenderDrawable(canvas *Canvas, mesh Drawable) int {
 			break
 		}
 
-		triangle := poly.Shape
-		color := Vector3FromColor(poly.Color)
+		count += f.drawPolygon(poly)
+	}
 
-		// Calculate normal in world space
-		line1 := triangle[2].ToVector3().Sub(triangle[0].ToVector3())
-		line2 := triangle[1].ToVector3().Sub(triangle[0].ToVector3())
-		normal := line1.Cross(line2).Normalize()
+	return count
+}
 
-		// Move to view space
-		triangle = view.TransformTriangle3(triangle)
+// Lights, clips and rasterizes a polygon. Returns the number of triangles drawn.
+func (f *frame) drawPolygon(poly Polygon) int {
+	triangle := poly.Shape
+	surf := surface{ambient: Vector3{1, 1, 1}, diffuse: Vector3FromColor(poly.Color), texture: poly.Texture}
+	opacity := 1.0
+	if material := poly.Material; material != nil {
+		surf.ambient = material.Ambient
+		surf.diffuse = material.Diffuse
+		surf.specular = material.Specular
+		surf.shininess = material.Shininess
+		opacity = material.Opacity
+	}
 
-		// Clip triangles outside the view frustrum
-		triangles := clipTriangle(nearPlane, triangle)
+	// Light whichever side is facing the camera, so winding order doesn't matter
+	normal := triangle.Normal()
+	if normal.Dot(f.toCamera(triangle.Centroid())) < 0 {
+		normal = normal.Scale(-1)
+	}
+	normals := poly.Normals
+	for i := range normals {
+		if normals[i].Magnitude() == 0 {
+			normals[i] = normal
+		} else if normals[i].Dot(normal) < 0 {
+			normals[i] = normals[i].Scale(-1)
+		}
+	}
 
-		for _, triangle = range triangles {
-			count += 1
-			triangle = proj.TransformTriangle3(triangle)
+	cellFor := func(color Vector3) Cell {
+		return Cell{
+			Fg:     color.Scale(0.7).ToColor(),
+			Bg:     color.ToColor().WithAlpha(opacity),
+			Sprite: ' ',
+		}
+	}
 
-			ambient := 0.1
-			diffuse := normal.Dot(lightDir)
-			light := ambient + diffuse
-			if light < ambient {
-				light = ambient
-			}
-			if light > 1 {
-				light = 1
+	// Values interpolated across the triangle. UVs come first, when textured.
+	var values [3][]float64
+	if surf.texture != nil {
+		for i, uv := range poly.UVs {
+			values[i] = append(values[i], uv[0], uv[1])
+		}
+	}
+	albedo := func(attrs []float64) Vector3 {
+		if surf.texture == nil {
+			return surf.diffuse
+		}
+		return surf.texture.Sample(Vector2{attrs[0], attrs[1]})
+	}
+	n := len(values[0])
+
+	// How to turn the values into a colour
+	var shader FragmentShader
+	switch poly.Shading {
+	case GouraudShading:
+		// Light arriving at each vertex
+		for i := range values {
+			diffuse, specular := f.illuminate(&surf, triangle[i], normals[i])
+			values[i] = append(values[i], diffuse[:]...)
+			values[i] = append(values[i], specular[:]...)
+		}
+		shader = func(frag *Fragment) (Cell, bool) {
+			attrs := frag.Attributes
+			diffuse := Vector3{attrs[n+0], attrs[n+1], attrs[n+2]}
+			specular := Vector3{attrs[n+3], attrs[n+4], attrs[n+5]}
+			return cellFor(surf.shade(albedo(attrs), diffuse, specular)), true
+		}
+
+	case PhongShading:
+		// Normals and world positions, so every pixel can be lit separately
+		for i, point := range triangle {
+			values[i] = append(values[i], normals[i][:]...)
+			values[i] = append(values[i], point[:]...)
+		}
+		shader = func(frag *Fragment) (Cell, bool) {
+			attrs := frag.Attributes
+			normal := Vector3{attrs[n+0], attrs[n+1], attrs[n+2]}.Normalize()
+			point := Point3{attrs[n+3], attrs[n+4], attrs[n+5]}
+			diffuse, specular := f.illuminate(&surf, point, normal)
+			return cellFor(surf.shade(albedo(attrs), diffuse, specular)), true
+		}
+
+	default:
+		diffuse, specular := f.illuminate(&surf, triangle.Centroid(), normal)
+		cell := cellFor(surf.shade(surf.diffuse, diffuse, specular))
+		shader = func(frag *Fragment) (Cell, bool) {
+			if surf.texture == nil {
+				return cell, true
 			}
-			color = color.Scale(light)
+			return cellFor(surf.shade(albedo(frag.Attributes), diffuse, specular)), true
+		}
+	}
+
+	// Move to view space and clip triangles outside the view frustrum
+	triangles := clipTriangleToPlanes(f.planes, f.view.TransformTriangle3(triangle))
+
+	for _, clipped := range triangles {
+		verts := [3]Vertex{}
+		var attributes [3][]float64
+		if len(values[0]) > 0 {
+			attributes = clipped.Interpolate(values)
+		}
+		for i, point := range clipped.Shape {
+			verts[i] = projectVertex(f.proj, point, attributes[i])
+		}
+		f.canvas.RasterizeTriangle(verts, shader)
+	}
+
+	return len(triangles)
+}
+
+// Draws the lines of a mesh as braille dots
+func (r *Renderer) RenderLines(canvas *Canvas, mesh LineDrawable) int {
+	count := 0
+	camera := &r.Camera
+
+	proj := camera.Projection
+	view := camera.View()
+	planes := frustumPlanes(proj)
+
+	ch := make(chan LineSegment, 100)
+	go func() {
+		mesh.DrawLines(ch)
+		close(ch)
+	}()
+
+	for segment := range ch {
+		// Move to view space
+		line := Line3{
+			view.TransformPoint3(segment.Shape[0]),
+			view.TransformPoint3(segment.Shape[1]),
+		}
+
+		// Clip lines outside the view frustrum
+		line, visible := clipLine(planes, line)
+		if !visible {
+			continue
+		}
 
-			canvas.DrawTriangle3(triangle, Cell{
-				Fg:     color.Scale(0.7).ToColor(),
-				Bg:     color.ToColor(),
-				Sprite: ' ',
-			})
+		count += 1
+		line = Line3{
+			proj.TransformPoint3(line[0]),
+			proj.TransformPoint3(line[1]),
 		}
+		canvas.DrawBrailleLine3(line, Color(segment.Color))
 	}
 
 	return count
@@ -0,0 +1,88 @@
+package renderer_test
+
+import (
+	"testing"
+	. "tri/canvas"
+	. "tri/geom"
+	. "tri/mesh"
+	. "tri/renderer"
+)
+
+func TestSmoothShadingWithoutTexture(t *testing.T) {
+	for _, shading := range []Shading{GouraudShading, PhongShading} {
+		canvas := NewCanvas(40, 20)
+		canvas.ClearWithCell(Cell{Depth: 1000000, Sprite: ' '})
+		renderer := Renderer{
+			Camera: Camera{
+				Projection: NewMatrix4Perspective(2, 45, 0.1, 1000.0),
+				Transform:  NewTransform(),
+			},
+		}
+		renderer.Camera.Transform.Translation = Vector3{0, 0, 6}
+
+		cube := NewTriangleMeshCube()
+		cube.Transform.Rotation = Vector3{0.5, 0.5, 0}
+		cube.SetShading(shading)
+		if count := renderer.RenderDrawable(&canvas, &cube); count == 0 {
+			t.Errorf("Shading %d: cube wasn't drawn", shading)
+			continue
+		}
+
+		lit := 0
+		for y := 0; y < canvas.PixelHeight(); y++ {
+			for x := 0; x < canvas.PixelWidth(); x++ {
+				if cell := canvas.Get(x, y); cell.Depth < 1000000 && cell.Bg&0xffffff != 0 {
+					lit++
+				}
+			}
+		}
+		if lit == 0 {
+			t.Errorf("Shading %d: expected lit pixels", shading)
+		}
+	}
+}
+
+func TestSmoothShadingDiffersFromFlat(t *testing.T) {
+	// A square facing the camera, with normals leaning further left and right across it
+	square := TriangleMesh{
+		Transform: NewTransform(),
+		Vertices:  []Point3{{-1, -1, 0}, {1, -1, 0}, {1, 1, 0}, {-1, 1, 0}},
+		Triangles: [][3]int{{0, 1, 2}, {2, 3, 0}},
+		Colors:    []uint32{0xffffffff, 0xffffffff},
+		VertexNormals: []Vector3{
+			Vector3{-1, 0, 1}.Normalize(), Vector3{1, 0, 1}.Normalize(),
+			Vector3{1, 0, 1}.Normalize(), Vector3{-1, 0, 1}.Normalize(),
+		},
+	}
+
+	colors := func(shading Shading) map[Color]bool {
+		canvas := NewCanvas(40, 20)
+		canvas.ClearWithCell(Cell{Depth: 1000000, Sprite: ' '})
+		renderer := Renderer{Camera: NewCamera(PerspectiveProjection)}
+		renderer.Camera.Transform.Translation = Vector3{0, 0, 4}
+		renderer.Camera.SetViewport(40, 20, 0.5)
+
+		square.Shading = shading
+		renderer.RenderDrawable(&canvas, &square)
+
+		seen := map[Color]bool{}
+		for y := 0; y < canvas.PixelHeight(); y++ {
+			for x := 0; x < canvas.PixelWidth(); x++ {
+				if cell := canvas.Get(x, y); cell.Depth < 1000000 {
+					seen[cell.Bg] = true
+				}
+			}
+		}
+		return seen
+	}
+
+	// Flat shading lights the whole square the same, smooth shading blends across it
+	if flat := colors(FlatShading); len(flat) != 1 {
+		t.Errorf("Expected flat shading to be one colour, got %d", len(flat))
+	}
+	for _, shading := range []Shading{GouraudShading, PhongShading} {
+		if smooth := colors(shading); len(smooth) < 4 {
+			t.Errorf("Shading %d: expected the colour to change across the square, got %d colours", shading, len(smooth))
+		}
+	}
+}
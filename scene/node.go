@@ -0,0 +1,162 @@
+package scene
+
+import (
+	. "tri/geom"
+	. "tri/mesh"
+	. "tri/renderer"
+)
+
+// Something in a scene graph. Nodes are placed relative to their parent, so moving
+// a node moves all of its children with it.
+type Node struct {
+	Name string
+	// Drawn with the node's transform. nil for nodes that only group others.
+	Mesh *TriangleMesh
+	// Hides the node and all of its children
+	Hidden bool
+
+	transform Transform
+	parent    *Node
+	children  []*Node
+	// Cached transform from the node's space to world space, and if it needs working out again
+	world Matrix4
+	dirty bool
+	// Set while the node is in a Scene
+	handle Handle
+}
+
+func NewNode(name string) *Node {
+	return &Node{
+		Name:      name,
+		transform: NewTransform(),
+		dirty:     true,
+	}
+}
+
+func NewMeshNode(name string, mesh *TriangleMesh) *Node {
+	node := NewNode(name)
+	node.Mesh = mesh
+	return node
+}
+
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+func (n *Node) Children() []*Node {
+	return n.children
+}
+
+// Attaches a node under this one, taking it away from its old parent
+func (n *Node) AddChild(child *Node) {
+	child.Remove()
+	child.parent = n
+	n.children = append(n.children, child)
+	child.invalidate()
+}
+
+// Detaches a node from its parent
+func (n *Node) Remove() {
+	if n.parent == nil {
+		return
+	}
+	siblings := n.parent.children
+	for i, sibling := range siblings {
+		if sibling == n {
+			n.parent.children = append(siblings[:i:i], siblings[i+1:]...)
+			break
+		}
+	}
+	n.parent = nil
+	n.invalidate()
+}
+
+// Position, rotation and scale relative to the parent
+func (n *Node) Transform() Transform {
+	return n.transform
+}
+
+func (n *Node) SetTransform(transform Transform) {
+	n.transform = transform
+	n.invalidate()
+}
+
+func (n *Node) SetTranslation(translation Vector3) {
+	n.transform.Translation = translation
+	n.invalidate()
+}
+
+func (n *Node) SetRotation(rotation Vector3) {
+	n.transform.Rotation = rotation
+	n.invalidate()
+}
+
+func (n *Node) SetScaling(scaling Vector3) {
+	n.transform.Scaling = scaling
+	n.invalidate()
+}
+
+// Marks the world transform of the node and everything under it as out of date
+func (n *Node) invalidate() {
+	if n.dirty {
+		// Children are already marked, since they're marked whenever their parent is
+		return
+	}
+	n.dirty = true
+	for _, child := range n.children {
+		child.invalidate()
+	}
+}
+
+// Transform from the node's own space to world space
+func (n *Node) WorldMatrix() Matrix4 {
+	if n.dirty {
+		n.world = n.transform.Matrix()
+		if n.parent != nil {
+			n.world = n.parent.WorldMatrix().Multiply(n.world)
+		}
+		n.dirty = false
+	}
+	return n.world
+}
+
+// Position of the node's origin in world space
+func (n *Node) WorldPosition() Point3 {
+	return n.WorldMatrix().TransformPoint3(Point3{0, 0, 0})
+}
+
+// Visits the node and everything under it, depth first, parents before children.
+// Returning false from visit skips the node's children.
+func (n *Node) Walk(visit func(node *Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for _, child := range n.children {
+		child.Walk(visit)
+	}
+}
+
+// Finds the first node with a name, searching depth first. nil if there's none.
+func (n *Node) Find(name string) *Node {
+	var found *Node
+	n.Walk(func(node *Node) bool {
+		if found == nil && node.Name == name {
+			found = node
+		}
+		return found == nil
+	})
+	return found
+}
+
+// Draws the meshes of every visible node, each inside the transforms of its parents
+func (n *Node) DrawTriangles(ch chan<- Polygon) {
+	n.Walk(func(node *Node) bool {
+		if node.Hidden {
+			return false
+		}
+		if node.Mesh != nil {
+			node.Mesh.DrawTransformedTriangles(node.WorldMatrix(), ch)
+		}
+		return true
+	})
+}
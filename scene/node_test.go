@@ -0,0 +1,114 @@
+package scene
+
+import (
+	"math"
+	"testing"
+	. "tri/geom"
+	. "tri/mesh"
+	. "tri/renderer"
+)
+
+func assertPoint3Near(t *testing.T, actual Point3, expected Point3) {
+	t.Helper()
+	for i := range actual {
+		if math.Abs(actual[i]-expected[i]) > 0.001 {
+			t.Errorf("Expected %v, got %v", expected, actual)
+			return
+		}
+	}
+}
+
+// Draws a node and counts the polygons it sends
+func countPolygons(node *Node) int {
+	ch := make(chan Polygon)
+	go func() {
+		node.DrawTriangles(ch)
+		close(ch)
+	}()
+	count := 0
+	for range ch {
+		count++
+	}
+	return count
+}
+
+func TestNodeWorldMatrix(t *testing.T) {
+	parent := NewNode("parent")
+	parent.SetTranslation(Vector3{10, 0, 0})
+	parent.SetScaling(Vector3{2, 2, 2})
+	child := NewNode("child")
+	child.SetTranslation(Vector3{1, 2, 3})
+	parent.AddChild(child)
+
+	assertPoint3Near(t, child.WorldPosition(), Point3{12, 4, 6})
+
+	// Moving the parent moves the child
+	parent.SetTranslation(Vector3{0, -5, 0})
+	assertPoint3Near(t, child.WorldPosition(), Point3{2, -1, 6})
+
+	parent.SetRotation(Vector3{0, math.Pi, 0})
+	assertPoint3Near(t, child.WorldPosition(), Point3{-2, -1, -6})
+}
+
+func TestNodeReparent(t *testing.T) {
+	a := NewNode("a")
+	a.SetTranslation(Vector3{5, 0, 0})
+	b := NewNode("b")
+	b.SetTranslation(Vector3{0, 5, 0})
+	child := NewNode("child")
+
+	a.AddChild(child)
+	assertPoint3Near(t, child.WorldPosition(), Point3{5, 0, 0})
+
+	b.AddChild(child)
+	if len(a.Children()) != 0 || len(b.Children()) != 1 || child.Parent() != b {
+		t.Errorf("Expected the child to move from a to b")
+	}
+	assertPoint3Near(t, child.WorldPosition(), Point3{0, 5, 0})
+
+	child.Remove()
+	if len(b.Children()) != 0 || child.Parent() != nil {
+		t.Errorf("Expected the child to be removed")
+	}
+	assertPoint3Near(t, child.WorldPosition(), Point3{0, 0, 0})
+}
+
+func TestNodeFind(t *testing.T) {
+	root := NewNode("root")
+	arm := NewNode("arm")
+	hand := NewNode("hand")
+	root.AddChild(arm)
+	arm.AddChild(hand)
+	root.AddChild(NewNode("leg"))
+
+	if root.Find("hand") != hand || root.Find("missing") != nil {
+		t.Errorf("Expected to find hand and nothing else")
+	}
+
+	var names []string
+	root.Walk(func(node *Node) bool {
+		names = append(names, node.Name)
+		return node != arm
+	})
+	if len(names) != 3 || names[0] != "root" || names[1] != "arm" || names[2] != "leg" {
+		t.Errorf("Expected to walk root, arm and leg, got %v", names)
+	}
+}
+
+func TestNodeHidden(t *testing.T) {
+	cube := NewTriangleMeshCube()
+	root := NewNode("root")
+	parent := NewMeshNode("parent", &cube)
+	parent.AddChild(NewMeshNode("child", &cube))
+	root.AddChild(parent)
+
+	perCube := len(cube.Triangles)
+	if count := countPolygons(root); count != 2*perCube {
+		t.Errorf("Expected %d polygons, got %d", 2*perCube, count)
+	}
+
+	parent.Hidden = true
+	if count := countPolygons(root); count != 0 {
+		t.Errorf("Expected hiding the parent to hide its child, got %d polygons", count)
+	}
+}
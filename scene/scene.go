@@ -1,36 +1,177 @@
 package scene
 
 import (
+	"sync"
+	. "tri/geom"
 	. "tri/mesh"
 	. "tri/renderer"
 )
 
+// Refers to a node added to a scene. Stays the same however the scene changes, and
+// never refers to another node once its node is removed. Zero is never a handle.
+type Handle uint64
+
+// A graph of nodes that can be changed from any goroutine. Nodes added to a scene
+// belong to it, and are only changed through Update. Draw a Snapshot of it each frame,
+// so the frame doesn't change halfway through being drawn.
 type Scene struct {
-	Meshes []TriangleMesh
+	mux  sync.Mutex
+	root *Node
+	// Lights shining on the meshes. When nil the renderer's default lights are used.
+	lights     []Light
+	nodes      map[Handle]*Node
+	nextHandle Handle
 }
 
-func NewScene() Scene {
+func NewScene() *Scene {
 	return NewSceneWith([]TriangleMesh{})
 }
 
-func NewSceneWith(meshes []TriangleMesh) Scene {
-	return Scene{meshes}
+func NewSceneWith(meshes []TriangleMesh) *Scene {
+	scene := &Scene{
+		root:  NewNode("root"),
+		nodes: map[Handle]*Node{},
+	}
+	for _, mesh := range meshes {
+		scene.Add(mesh)
+	}
+	return scene
+}
+
+// Adds a mesh in its own node under the root
+func (s *Scene) Add(mesh TriangleMesh) Handle {
+	return s.AddNode(NewMeshNode("", &mesh))
 }
 
-func (s *Scene) Mesh(idx int) *TriangleMesh {
-	if idx < 0 || idx >= len(s.Meshes) {
+// Attaches a node, and everything under it, to the root
+func (s *Scene) AddNode(node *Node) Handle {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.attach(s.root, node)
+}
+
+// Attaches a node under another one. Returns 0 if the parent isn't in the scene.
+func (s *Scene) AddChild(parent Handle, node *Node) Handle {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	parentNode, ok := s.nodes[parent]
+	if !ok {
+		return 0
+	}
+	return s.attach(parentNode, node)
+}
+
+func (s *Scene) attach(parent, node *Node) Handle {
+	s.nextHandle++
+	node.handle = s.nextHandle
+	s.nodes[node.handle] = node
+	parent.AddChild(node)
+	return node.handle
+}
+
+// Takes a node, and everything under it, out of the scene. Returns false if it wasn't there.
+func (s *Scene) Remove(handle Handle) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	node, ok := s.nodes[handle]
+	if !ok {
+		return false
+	}
+	node.Remove()
+	node.Walk(func(child *Node) bool {
+		if child.handle != 0 {
+			delete(s.nodes, child.handle)
+			child.handle = 0
+		}
+		return true
+	})
+	return true
+}
+
+// Changes a node while nothing else can. Returns false if it isn't in the scene.
+func (s *Scene) Update(handle Handle, update func(node *Node)) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	node, ok := s.nodes[handle]
+	if !ok {
+		return false
+	}
+	update(node)
+	return true
+}
+
+func (s *Scene) AddLight(light Light) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.lights = append(s.lights, light)
+}
+
+// Replaces every light. nil goes back to the renderer's default lights.
+func (s *Scene) SetLights(lights []Light) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.lights = copyLights(lights)
+}
+
+func (s *Scene) Lighting() []Light {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return copyLights(s.lights)
+}
+
+func copyLights(lights []Light) []Light {
+	if lights == nil {
 		return nil
 	}
-	return &s.Meshes[idx]
+	return append([]Light{}, lights...)
 }
 
-func (s *Scene) Add(mesh TriangleMesh) int {
-	s.Meshes = append(s.Meshes, mesh)
-	return len(s.Meshes) - 1
+// Copies everything needed to draw the scene as it is now. Meshes share their
+// geometry with the scene, so replace a mesh rather than changing its vertices.
+func (s *Scene) Snapshot() *Snapshot {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	snapshot := &Snapshot{lights: copyLights(s.lights)}
+	s.root.Walk(func(node *Node) bool {
+		if node.Hidden {
+			return false
+		}
+		if node.Mesh != nil {
+			snapshot.meshes = append(snapshot.meshes, snapshotMesh{
+				mesh:  *node.Mesh,
+				world: node.WorldMatrix(),
+			})
+		}
+		return true
+	})
+	return snapshot
 }
 
+// Draws a snapshot of the scene. Lights can change between this and Lighting,
+// so draw a Snapshot instead when they need to match.
 func (s *Scene) DrawTriangles(ch chan<- Polygon) {
-	for _, mesh := range s.Meshes {
-		mesh.DrawTriangles(ch)
+	s.Snapshot().DrawTriangles(ch)
+}
+
+// A scene frozen at one moment, safe to draw while the scene keeps changing
+type Snapshot struct {
+	meshes []snapshotMesh
+	lights []Light
+}
+
+type snapshotMesh struct {
+	mesh  TriangleMesh
+	world Matrix4
+}
+
+func (s *Snapshot) DrawTriangles(ch chan<- Polygon) {
+	for i := range s.meshes {
+		m := &s.meshes[i]
+		m.mesh.DrawTransformedTriangles(m.world, ch)
 	}
 }
+
+func (s *Snapshot) Lighting() []Light {
+	return s.lights
+}
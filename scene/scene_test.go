@@ -0,0 +1,100 @@
+package scene
+
+import (
+	"sync"
+	"testing"
+	. "tri/geom"
+	. "tri/mesh"
+	. "tri/renderer"
+)
+
+func TestSceneHandles(t *testing.T) {
+	scene := NewScene()
+	cube := scene.Add(NewTriangleMeshCube())
+	arm := scene.AddNode(NewNode("arm"))
+	hand := scene.AddChild(arm, NewNode("hand"))
+	if cube == 0 || arm == 0 || hand == 0 || cube == arm || arm == hand {
+		t.Fatalf("Expected different handles, got %d, %d and %d", cube, arm, hand)
+	}
+	if scene.AddChild(Handle(100), NewNode("lost")) != 0 {
+		t.Errorf("Expected no handle when the parent is missing")
+	}
+
+	// Removing a node removes everything under it
+	if !scene.Remove(arm) {
+		t.Errorf("Expected arm to be removed")
+	}
+	if scene.Remove(arm) || scene.Update(hand, func(*Node) {}) {
+		t.Errorf("Expected arm and hand to be gone")
+	}
+
+	// Handles aren't reused
+	if again := scene.AddNode(NewNode("arm")); again == arm || again == hand {
+		t.Errorf("Expected a new handle, got %d again", again)
+	}
+	if !scene.Update(cube, func(node *Node) { node.SetTranslation(Vector3{1, 2, 3}) }) {
+		t.Errorf("Expected the cube to still be there")
+	}
+}
+
+func TestSnapshotDoesNotChange(t *testing.T) {
+	scene := NewScene()
+	cube := scene.Add(NewTriangleMeshCube())
+	scene.AddLight(NewAmbientLight(Vector3{1, 1, 1}, 0.5))
+	snapshot := scene.Snapshot()
+
+	scene.Update(cube, func(node *Node) {
+		node.SetTranslation(Vector3{10, 0, 0})
+		node.Mesh.Transform.Translation = Vector3{0, 10, 0}
+	})
+	scene.Add(NewTriangleMeshCube())
+	scene.SetLights(nil)
+
+	if len(snapshot.meshes) != 1 || snapshot.meshes[0].world != NewMatrix4Identity() || snapshot.meshes[0].mesh.Transform.Translation != (Vector3{}) {
+		t.Errorf("Expected the snapshot to keep the cube where it was")
+	}
+	if len(snapshot.Lighting()) != 1 || scene.Lighting() != nil {
+		t.Errorf("Expected the snapshot to keep its light")
+	}
+}
+
+func TestSceneConcurrentChanges(t *testing.T) {
+	scene := NewScene()
+	cube := scene.Add(NewTriangleMeshCube())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			handle := scene.Add(NewTriangleMeshCube())
+			if i%2 == 0 {
+				scene.Remove(handle)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			scene.Update(cube, func(node *Node) {
+				node.SetRotation(Vector3{float64(i), 0, 0})
+			})
+			scene.AddLight(NewAmbientLight(Vector3{1, 1, 1}, 0.01))
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		ch := make(chan Polygon, 100)
+		go func() {
+			scene.DrawTriangles(ch)
+			close(ch)
+		}()
+		for range ch {
+		}
+	}
+	wg.Wait()
+
+	if count := len(scene.Snapshot().meshes); count != 26 {
+		t.Errorf("Expected 26 meshes, got %d", count)
+	}
+}
@@ -0,0 +1,126 @@
+package terminal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Width of a terminal cell divided by its height, in most fonts
+const DefaultCellAspect = 0.5
+
+// Size of the terminal's text area in pixels. 0, 0 when the terminal doesn't say.
+func (t *Terminal) PixelSize() (int, int) {
+	t.sizeMux.Lock()
+	defer t.sizeMux.Unlock()
+	if t.pixelWidth > 0 && t.pixelHeight > 0 {
+		return t.pixelWidth, t.pixelHeight
+	}
+	cellWidth, cellHeight := t.cellSize()
+	return int(cellWidth*float64(t.width) + 0.5), int(cellHeight*float64(t.height) + 0.5)
+}
+
+// Size of a cell in pixels. 0, 0 when the terminal doesn't say.
+func (t *Terminal) CellSize() (float64, float64) {
+	t.sizeMux.Lock()
+	defer t.sizeMux.Unlock()
+	return t.cellSize()
+}
+
+func (t *Terminal) cellSize() (float64, float64) {
+	if t.pixelWidth > 0 && t.pixelHeight > 0 && t.width > 0 && t.height > 0 {
+		return float64(t.pixelWidth) / float64(t.width), float64(t.pixelHeight) / float64(t.height)
+	}
+	return t.cellWidth, t.cellHeight
+}
+
+// Width of a cell divided by its height, for working out how things should be
+// stretched to look the right shape. Checks in order:
+//
+//	SetCellAspect, or the TRI_CELL_ASPECT environment variable
+//	The size in pixels reported by the kernel, or by DetectCellSize
+//	DefaultCellAspect
+func (t *Terminal) CellAspect() float64 {
+	if t.cellAspect > 0 {
+		return t.cellAspect
+	}
+	if width, height := t.CellSize(); width > 0 && height > 0 {
+		return width / height
+	}
+	return DefaultCellAspect
+}
+
+// Overrides the cell aspect ratio, for terminals that get it wrong. 0 goes back to detecting it.
+func (t *Terminal) SetCellAspect(aspect float64) {
+	t.cellAspect = aspect
+}
+
+// Reads a cell aspect ratio, either as a number or a cell size like 8x16.
+// Returns 0 if it's not either.
+func ParseCellAspect(value string) float64 {
+	if size := strings.SplitN(value, "x", 2); len(size) == 2 {
+		w, werr := strconv.ParseFloat(size[0], 64)
+		h, herr := strconv.ParseFloat(size[1], 64)
+		if werr != nil || herr != nil || w <= 0 || h <= 0 {
+			return 0
+		}
+		return w / h
+	}
+	aspect, err := strconv.ParseFloat(value, 64)
+	if err != nil || aspect <= 0 {
+		return 0
+	}
+	return aspect
+}
+
+func detectCellAspect(getenv func(string) string) float64 {
+	return ParseCellAspect(getenv("TRI_CELL_ASPECT"))
+}
+
+// Asks the terminal for the size of its cells in pixels, when the kernel doesn't know it.
+// Tries CSI 16 t for the size of a cell, then CSI 14 t for the size of the text area.
+// Returns false if the size is still unknown. Call it before anything else reads input.
+func (t *Terminal) DetectCellSize() bool {
+	if width, height := t.CellSize(); width > 0 && height > 0 {
+		return true
+	}
+	reports := t.query("\x1b[16t\x1b[14t", queryTimeout)
+	columns, rows := t.Size()
+	width, height := cellSizeFromReports(reports, columns, rows)
+
+	t.sizeMux.Lock()
+	defer t.sizeMux.Unlock()
+	t.cellWidth, t.cellHeight = width, height
+	return width > 0 && height > 0
+}
+
+// Finds the size of a cell in answers to CSI 16 t (CSI 6 ; height ; width t),
+// or failing that CSI 14 t (CSI 4 ; height ; width t) divided by the number of cells
+func cellSizeFromReports(reports []queryReport, columns, rows int) (float64, float64) {
+	var areaWidth, areaHeight float64
+	for _, report := range reports {
+		if report.Final != 't' {
+			continue
+		}
+		params := strings.Split(report.Params, ";")
+		if len(params) != 3 {
+			continue
+		}
+		height, herr := strconv.Atoi(params[1])
+		width, werr := strconv.Atoi(params[2])
+		if herr != nil || werr != nil || width <= 0 || height <= 0 {
+			continue
+		}
+
+		switch params[0] {
+		case "6":
+			return float64(width), float64(height)
+		case "4":
+			areaWidth, areaHeight = float64(width), float64(height)
+		}
+	}
+
+	if areaWidth > 0 && columns > 0 && rows > 0 {
+		return areaWidth / float64(columns), areaHeight / float64(rows)
+	}
+	return 0, 0
+}
@@ -0,0 +1,84 @@
+package terminal
+
+import (
+	"testing"
+)
+
+func TestCellSizeFromReports(t *testing.T) {
+	tests := []struct {
+		input         string
+		width, height float64
+	}{
+		{"\x1b[6;16;8t\x1b[4;384;640t\x1b[?62c", 8, 16},
+		// Only the size of the text area, spread over 80 by 24 cells
+		{"\x1b[4;384;640t\x1b[?62c", 8, 16},
+		{"\x1b[?62c", 0, 0},
+		{"\x1b[6;0;0t\x1b[?62c", 0, 0},
+	}
+
+	for _, test := range tests {
+		reports, answered, rest := parseQueryReports([]byte(test.input))
+		width, height := cellSizeFromReports(reports, 80, 24)
+		if !answered || len(rest) != 0 || width != test.width || height != test.height {
+			t.Errorf("Parsing %q: expected %vx%v, got %vx%v (answered %v, rest %q)", test.input, test.width, test.height, width, height, answered, rest)
+		}
+	}
+
+	// Keys pressed while waiting are kept
+	_, _, rest := parseQueryReports([]byte("a\x1b[A\x1b[6;16;8t\x1b[1;5C\x1b[?62c"))
+	if string(rest) != "a\x1b[A\x1b[1;5C" {
+		t.Errorf("Expected key presses to be kept, got %q", rest)
+	}
+}
+
+func TestCellAspect(t *testing.T) {
+	term := Terminal{width: 80, height: 24}
+	if aspect := term.CellAspect(); aspect != DefaultCellAspect {
+		t.Errorf("Expected the default aspect without a size, got %f", aspect)
+	}
+
+	// Answers to DetectCellSize
+	term.cellWidth, term.cellHeight = 9, 20
+	if aspect := term.CellAspect(); aspect != 0.45 {
+		t.Errorf("Expected 0.45 from the detected cell size, got %f", aspect)
+	}
+
+	// The kernel's size wins
+	term.pixelWidth, term.pixelHeight = 800, 480
+	if width, height := term.PixelSize(); width != 800 || height != 480 {
+		t.Errorf("Expected 800x480 pixels, got %dx%d", width, height)
+	}
+	if aspect := term.CellAspect(); aspect != 0.5 {
+		t.Errorf("Expected 0.5 from the kernel's size, got %f", aspect)
+	}
+	if !term.DetectCellSize() {
+		t.Errorf("Expected no need to ask when the size is known")
+	}
+
+	term.SetCellAspect(0.6)
+	if aspect := term.CellAspect(); aspect != 0.6 {
+		t.Errorf("Expected the override, got %f", aspect)
+	}
+}
+
+func TestParseCellAspect(t *testing.T) {
+	tests := map[string]float64{
+		"0.5":   0.5,
+		"8x16":  0.5,
+		"10x20": 0.5,
+		"":      0,
+		"-1":    0,
+		"0x16":  0,
+		"wide":  0,
+	}
+	for input, expected := range tests {
+		if aspect := ParseCellAspect(input); aspect != expected {
+			t.Errorf("Parsing %q: expected %f, got %f", input, expected, aspect)
+		}
+	}
+
+	getenv := func(string) string { return "9x18" }
+	if aspect := detectCellAspect(getenv); aspect != 0.5 {
+		t.Errorf("Expected TRI_CELL_ASPECT to be used, got %f", aspect)
+	}
+}
@@ -0,0 +1,117 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// How many colours the terminal can show
+type ColorMode uint8
+
+const (
+	// No colours, only normal and reverse video
+	MonoColor ColorMode = iota
+	// The 16 standard colours
+	Ansi16Color
+	// The xterm 256 colour palette
+	Ansi256Color
+	// Any 24 bit colour
+	TrueColor
+)
+
+func (m ColorMode) String() string {
+	switch m {
+	case MonoColor:
+		return "mono"
+	case Ansi16Color:
+		return "16"
+	case Ansi256Color:
+		return "256"
+	case TrueColor:
+		return "truecolor"
+	}
+	return fmt.Sprintf("ColorMode(%d)", uint8(m))
+}
+
+// Reads a colour mode name, as used by String or the TRI_COLORS environment variable
+func ParseColorMode(name string) (ColorMode, error) {
+	switch strings.ToLower(name) {
+	case "mono", "monochrome", "none", "2":
+		return MonoColor, nil
+	case "16", "8", "ansi":
+		return Ansi16Color, nil
+	case "256":
+		return Ansi256Color, nil
+	case "truecolor", "24bit", "rgb", "direct":
+		return TrueColor, nil
+	}
+	return MonoColor, fmt.Errorf("unknown colour mode %q", name)
+}
+
+// Works out how many colours the terminal supports, checking in order:
+//
+//	TRI_COLORS, a user override using a name from ParseColorMode
+//	NO_COLOR, which turns colours off
+//	COLORTERM and TERM_PROGRAM, which truecolor terminals set
+//	The `colors` capability of TERM's terminfo entry
+//	Guessing from TERM's name
+func DetectColorMode() ColorMode {
+	return detectColorMode(os.Getenv, terminfoColors)
+}
+
+func detectColorMode(getenv func(string) string, colors func(term string) (int, error)) ColorMode {
+	if override := getenv("TRI_COLORS"); override != "" {
+		if mode, err := ParseColorMode(override); err == nil {
+			return mode
+		}
+	}
+
+	if getenv("NO_COLOR") != "" {
+		return MonoColor
+	}
+
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "Hyper":
+		return TrueColor
+	}
+
+	term := getenv("TERM")
+	if term == "" || term == "dumb" {
+		return MonoColor
+	}
+	if strings.HasSuffix(term, "-direct") || strings.Contains(term, "truecolor") || strings.Contains(term, "24bit") {
+		return TrueColor
+	}
+
+	if count, err := colors(term); err == nil {
+		switch {
+		case count >= 1<<24:
+			return TrueColor
+		case count >= 256:
+			return Ansi256Color
+		case count >= 8:
+			return Ansi16Color
+		default:
+			return MonoColor
+		}
+	}
+
+	if strings.Contains(term, "256color") {
+		return Ansi256Color
+	}
+	return Ansi16Color
+}
+
+func (t *Terminal) ColorMode() ColorMode {
+	return t.colorMode
+}
+
+// Overrides the detected colour mode
+func (t *Terminal) SetColorMode(mode ColorMode) {
+	t.colorMode = mode
+}
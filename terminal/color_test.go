@@ -0,0 +1,119 @@
+package terminal
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestDetectColorMode(t *testing.T) {
+	terminfo := map[string]int{
+		"xterm":          8,
+		"xterm-256color": 256,
+		"linux":          8,
+		"vt100":          -1,
+	}
+	colors := func(term string) (int, error) {
+		if count, ok := terminfo[term]; ok {
+			return count, nil
+		}
+		return 0, errors.New("not found")
+	}
+
+	tests := []struct {
+		env      map[string]string
+		expected ColorMode
+	}{
+		{map[string]string{}, MonoColor},
+		{map[string]string{"TERM": "dumb", "COLORTERM": ""}, MonoColor},
+		{map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, TrueColor},
+		{map[string]string{"TERM": "xterm-256color", "COLORTERM": "24bit"}, TrueColor},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "iTerm.app"}, TrueColor},
+		{map[string]string{"TERM": "xterm-256color"}, Ansi256Color},
+		{map[string]string{"TERM": "xterm"}, Ansi16Color},
+		{map[string]string{"TERM": "linux"}, Ansi16Color},
+		{map[string]string{"TERM": "vt100"}, MonoColor},
+		{map[string]string{"TERM": "xterm-direct"}, TrueColor},
+		{map[string]string{"TERM": "tmux-256color"}, Ansi256Color},
+		{map[string]string{"TERM": "unknown"}, Ansi16Color},
+		{map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"}, MonoColor},
+		{map[string]string{"TERM": "linux", "COLORTERM": "truecolor", "TRI_COLORS": "256"}, Ansi256Color},
+		{map[string]string{"TERM": "linux", "TRI_COLORS": "nonsense"}, Ansi16Color},
+	}
+
+	for _, test := range tests {
+		getenv := func(key string) string {
+			return test.env[key]
+		}
+		if mode := detectColorMode(getenv, colors); mode != test.expected {
+			t.Errorf("Expected %v for %v, got %v", test.expected, test.env, mode)
+		}
+	}
+}
+
+// Builds a compiled terminfo entry with only numeric capabilities
+func buildTerminfo(magic int, names string, bools int, numbers []int) []byte {
+	numberSize := 2
+	if magic == terminfoExtendedMagic {
+		numberSize = 4
+	}
+
+	data := make([]byte, 12)
+	for i, v := range []int{magic, len(names) + 1, bools, len(numbers), 0, 0} {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(v))
+	}
+	data = append(data, names...)
+	data = append(data, 0)
+	data = append(data, make([]byte, bools)...)
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+	for _, n := range numbers {
+		number := make([]byte, numberSize)
+		if numberSize == 2 {
+			binary.LittleEndian.PutUint16(number, uint16(int16(n)))
+		} else {
+			binary.LittleEndian.PutUint32(number, uint32(int32(n)))
+		}
+		data = append(data, number...)
+	}
+	return data
+}
+
+func TestParseTerminfoColors(t *testing.T) {
+	numbers := make([]int, 15)
+	for i := range numbers {
+		numbers[i] = -1
+	}
+
+	numbers[terminfoMaxColors] = 256
+	count, err := parseTerminfoColors(buildTerminfo(terminfoMagic, "xterm-256color|xterm with 256 colors", 3, numbers))
+	if err != nil || count != 256 {
+		t.Errorf("Expected 256 colours, got %d %v", count, err)
+	}
+
+	numbers[terminfoMaxColors] = 1 << 24
+	count, err = parseTerminfoColors(buildTerminfo(terminfoExtendedMagic, "xterm-direct", 4, numbers))
+	if err != nil || count != 1<<24 {
+		t.Errorf("Expected 16777216 colours, got %d %v", count, err)
+	}
+
+	count, err = parseTerminfoColors(buildTerminfo(terminfoMagic, "vt100", 2, numbers[:5]))
+	if err != nil || count != -1 {
+		t.Errorf("Expected no colours, got %d %v", count, err)
+	}
+
+	if _, err := parseTerminfoColors([]byte("not terminfo at all")); err == nil {
+		t.Errorf("Expected an error for a broken terminfo file")
+	}
+}
+
+func TestTerminfoColors(t *testing.T) {
+	count, err := terminfoColors("xterm-256color")
+	if err != nil {
+		t.Skipf("No terminfo database: %v", err)
+	}
+	if count != 256 {
+		t.Errorf("Expected xterm-256color to have 256 colours, got %d", count)
+	}
+}
@@ -64,18 +64,22 @@ func (t *Terminal) Clear() {
 
 func (t *Terminal) AltScreen() {
 	t.CSI("?1049h")
+	t.addModes(altScreenMode)
 }
 
 func (t *Terminal) MainScreen() {
 	t.CSI("?1049l")
+	t.removeModes(altScreenMode)
 }
 
 func (t *Terminal) ShowCursor() {
 	t.CSI("?25h")
+	t.removeModes(hiddenCursorMode)
 }
 
 func (t *Terminal) HideCursor() {
 	t.CSI("?25l")
+	t.addModes(hiddenCursorMode)
 }
 
 func (t *Terminal) Color(code uint8) {
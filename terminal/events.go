@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Delivers input events on a channel, along with a ResizeEvent whenever the terminal
+// changes size. The channel is closed when ctx is done, or after an ErrorEvent when
+// input ends. Only one of Events or NextEvent should be reading input at a time.
+func (t *Terminal) Events(ctx context.Context) <-chan InputEvent {
+	events := make(chan InputEvent)
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+
+	go func() {
+		defer close(events)
+		defer signal.Stop(resized)
+
+		for {
+			event, ok := t.waitForEvent(ctx, resized)
+			if !ok {
+				return
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+
+			if event.EventType == ErrorEvent {
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// Sends a FocusEvent when the terminal gains or loses focus
+func (t *Terminal) EnableFocusEvents() {
+	t.CSI("?1004h")
+	t.addModes(focusEventsMode)
+}
+
+func (t *Terminal) DisableFocusEvents() {
+	t.CSI("?1004l")
+	t.removeModes(focusEventsMode)
+}
+
+// Sends pasted text as a single PasteEvent, instead of as key presses
+func (t *Terminal) EnableBracketedPaste() {
+	t.CSI("?2004h")
+	t.addModes(bracketedPasteMode)
+}
+
+func (t *Terminal) DisableBracketedPaste() {
+	t.CSI("?2004l")
+	t.removeModes(bracketedPasteMode)
+}
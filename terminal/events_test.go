@@ -0,0 +1,170 @@
+package terminal
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newPipedTerminal() (*Terminal, *io.PipeWriter) {
+	reader, writer := io.Pipe()
+	return &Terminal{stdin: *bufio.NewReader(reader)}, writer
+}
+
+// Waits for the next event on a channel, failing if it doesn't come
+func receiveEvent(t *testing.T, events <-chan InputEvent) InputEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatalf("Events closed early")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for an event")
+	}
+	return InputEvent{}
+}
+
+func TestDecodeFocusAndPaste(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected InputEvent
+	}{
+		{"\x1b[I", InputEvent{EventType: FocusEvent, Focused: true}},
+		{"\x1b[O", InputEvent{EventType: FocusEvent, Focused: false}},
+		{"\x1b[200~hello\x1b[201~", InputEvent{EventType: PasteEvent, Text: "hello"}},
+		{"\x1b[200~\x1b[A\r\nq\x1b[201~", InputEvent{EventType: PasteEvent, Text: "\x1b[A\r\nq"}},
+	}
+
+	for _, test := range tests {
+		event, size, ok := decodeEvent([]byte(test.input), false)
+		if !ok || size != len(test.input) || event != test.expected {
+			t.Errorf("Decoding %q: expected %+v, got %+v using %d bytes", test.input, test.expected, event, size)
+		}
+	}
+
+	// Pastes wait for the end marker, however long it takes
+	if _, size, _ := decodeEvent([]byte("\x1b[200~hello"), true); size != 0 {
+		t.Errorf("Expected an unfinished paste to wait for more input")
+	}
+}
+
+func TestEvents(t *testing.T) {
+	term, writer := newPipedTerminal()
+	events := term.Events(context.Background())
+
+	go func() {
+		writer.Write([]byte("a\x1b[I\x1b[200~pas"))
+		writer.Write([]byte("ted\x1b[201~"))
+		writer.Close()
+	}()
+
+	expected := []InputEvent{
+		newKeyEvent('a', 0),
+		{EventType: FocusEvent, Focused: true},
+		{EventType: PasteEvent, Text: "pasted"},
+		{EventType: ErrorEvent, Err: io.EOF},
+	}
+	for _, want := range expected {
+		if got := receiveEvent(t, events); got != want {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+
+	if _, ok := <-events; ok {
+		t.Errorf("Expected events to be closed after input ends")
+	}
+}
+
+func TestEventsCancel(t *testing.T) {
+	term, writer := newPipedTerminal()
+	ctx, cancel := context.WithCancel(context.Background())
+	events := term.Events(ctx)
+
+	go writer.Write([]byte("x"))
+	if event := receiveEvent(t, events); event.Key != 'x' {
+		t.Errorf("Expected x, got %+v", event)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Errorf("Expected no more events after cancelling")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Events wasn't closed after cancelling")
+	}
+
+	// Input that arrives later is read without anything taking it,
+	// and is still there for the next reader
+	written := make(chan struct{})
+	go func() {
+		writer.Write([]byte("y"))
+		writer.Write([]byte("z"))
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatalf("Reading input got stuck waiting for it to be taken")
+	}
+	for _, key := range "yz" {
+		if event := term.NextEvent(); event.Key != key {
+			t.Errorf("Expected %c, got %+v", key, event)
+		}
+	}
+}
+
+func TestEventsResize(t *testing.T) {
+	term, _ := newPipedTerminal()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := term.Events(ctx)
+
+	// Give Events a moment to start listening for the signal
+	time.Sleep(10 * time.Millisecond)
+	syscall.Kill(os.Getpid(), syscall.SIGWINCH)
+
+	if event := receiveEvent(t, events); event.EventType != ResizeEvent {
+		t.Errorf("Expected a resize, got %+v", event)
+	}
+}
+
+func TestEventsWhileChangingModes(t *testing.T) {
+	term, writer := newPipedTerminal()
+	term.stdout = *bufio.NewWriter(ioutil.Discard)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := term.Events(ctx)
+
+	// Modes change on one goroutine while another decodes mouse reports
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			term.Lock()
+			term.EnableMousePixels()
+			term.EnableKittyKeyboard(KittyKeyState)
+			term.DisableKittyKeyboard()
+			term.Unlock()
+		}
+	}()
+	go func() {
+		for i := 0; i < 100; i++ {
+			writer.Write([]byte("\x1b[<0;10;20M"))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if event := receiveEvent(t, events); event.EventType != MouseEvent {
+			t.Fatalf("Expected a mouse event, got %+v", event)
+		}
+	}
+	<-done
+}
@@ -1,12 +1,15 @@
 package terminal
 
 import (
-	"bufio"
-	"fmt"
+	"context"
 	"os"
-	"strconv"
+	"time"
 )
 
+// Long enough for the rest of a sequence to arrive over a slow connection,
+// short enough that pressing Escape doesn't feel sluggish
+const defaultEscapeTimeout = 50 * time.Millisecond
+
 type InputEventType uint8
 type MouseAction uint8
 type MouseButton uint8
@@ -14,6 +17,14 @@ type MouseButton uint8
 const (
 	KeyEvent InputEventType = iota
 	MouseEvent
+	// The terminal changed size, only sent by Events
+	ResizeEvent
+	// The terminal gained or lost focus, after EnableFocusEvents
+	FocusEvent
+	// Text was pasted, after EnableBracketedPaste
+	PasteEvent
+	// Reading input failed, or it ended with io.EOF
+	ErrorEvent
 )
 
 const (
@@ -22,189 +33,177 @@ const (
 	MouseMiddle
 	MouseScrollUp
 	MouseScrollDown
+	MouseScrollLeft
+	MouseScrollRight
+	// The side buttons, usually used to go back and forward
+	MouseBack
+	MouseForward
+	// Moving without a button held, or a release in X10 mode, which doesn't say which button
+	MouseNoButton
 )
 
 const (
 	MouseDown MouseAction = iota
 	MouseUp
+	// Moving with a button held
 	MouseDrag
+	// Moving without a button held, only reported after EnableMouseMove
 	MouseMove
+	// The wheel turned, in the direction of the MouseButton
+	MouseWheel
 )
 
 type InputEvent struct {
-	EventType   InputEventType
-	Key         rune
+	EventType InputEventType
+	// A character, or one of the named keys such as KeyUp
+	Key       rune
+	Modifiers KeyModifier
+	// Only terminals using the kitty keyboard protocol report repeats and releases
+	KeyAction   KeyAction
 	MouseButton MouseButton
 	MouseAction MouseAction
+	// Position in cells from the top left, or pixels if MousePixels is set
 	MouseX      int
 	MouseY      int
+	MousePixels bool
+	// Size of the terminal in cells, for ResizeEvent
+	Width, Height int
+	Focused       bool
+	Text          string
+	Err           error
 }
 
-// Waits for the next user input event. Either mouse or keyboard
+// Waits for the next user input event: a key, the mouse, focus, a paste,
+// or an ErrorEvent once input has ended
 func (t *Terminal) NextEvent() InputEvent {
-	b := nextByte(&t.stdin)
-	if t.stdin.Buffered() > 0 && b == '\x1b' && isCtrlChar(peekByte(&t.stdin)) {
-		return NewInputEventFromCtrlChar(&t.stdin)
-	}
-
-	return NewInputEventFromKey(b)
+	event, _ := t.waitForEvent(context.Background(), nil)
+	return event
 }
 
-func NewInputEventFromKey(b byte) InputEvent {
-	return InputEvent{
-		EventType: KeyEvent,
-		Key:       rune(b),
-	}
-}
-func NewInputEventFromKeyboard(stream *bufio.Reader) InputEvent {
-	return InputEvent{
-		EventType: KeyEvent,
-		Key:       nextRune(stream),
+// Waits for the next input event, or a resize signal. Returns false if ctx is done first.
+func (t *Terminal) waitForEvent(ctx context.Context, resized <-chan os.Signal) (InputEvent, bool) {
+	if t.inputReady == nil {
+		t.inputReady = make(chan struct{}, 1)
+		go t.readInput()
 	}
-}
 
-func NewInputEventFromCtrlChar(stream *bufio.Reader) InputEvent {
-	b := nextRune(stream)
-	switch b {
-	case '[':
-		return NewInputEventFromCtrlSeq(stream)
-
-	default:
-		// Unsupported control char
-		return InputEvent{}
+	// Part of a sequence has arrived. If the rest doesn't follow soon, it wasn't a sequence,
+	// e.g. Escape was pressed on its own.
+	var timer *time.Timer
+	stopTimer := func() {
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
 	}
+	defer stopTimer()
 
-}
+	for {
+		if event, ok := t.decodePending(false); ok {
+			return event, true
+		}
+		if t.inputErr != nil {
+			if event, ok := t.decodePending(true); ok {
+				return event, true
+			}
+			return InputEvent{EventType: ErrorEvent, Err: t.inputErr}, true
+		}
 
-// Parse a CSI string into an InputEvent. Assumes CSI prefix has been removed.
-// See: http://www.xfree86.org/4.7.0/ctlseqs.html
-func NewInputEventFromCtrlSeq(stream *bufio.Reader) InputEvent {
-	b := nextByte(stream)
-	switch b {
-	case 'M':
-		return NewInputEventFromX10Mouse(stream)
+		var timeout <-chan time.Time
+		if len(t.pending) > 0 {
+			timer = time.NewTimer(t.EscapeTimeout())
+			timeout = timer.C
+		}
 
-	case '<':
-		return NewInputEventFromSGRMouse(stream)
+		select {
+		case <-t.inputReady:
+			data, err := t.takeInput()
+			t.pending = append(t.pending, data...)
+			t.inputErr = err
 
-	default:
-		return NewInputEventFromKey(b)
-	}
-}
+		case <-timeout:
+			if event, ok := t.decodePending(true); ok {
+				return event, true
+			}
 
-func NewInputEventFromX10Mouse(stream *bufio.Reader) InputEvent {
-	button := nextByte(stream) - 32
-	x := int(nextByte(stream)) - 33
-	y := int(nextByte(stream)) - 33
+		case <-resized:
+			t.UpdateSize()
+			width, height := t.Size()
+			return InputEvent{EventType: ResizeEvent, Width: width, Height: height}, true
 
-	// FIXME Support MouseUp
-	action := MouseDown
+		case <-ctx.Done():
+			return InputEvent{}, false
+		}
 
-	return InputEvent{
-		EventType:   MouseEvent,
-		MouseButton: MouseButton(button),
-		MouseAction: action,
-		MouseX:      x,
-		MouseY:      y,
+		stopTimer()
 	}
 }
 
-func NewInputEventFromSGRMouse(stream *bufio.Reader) InputEvent {
-	button := nextByte(stream) - 48
-	action := MouseDown
-	x := 0
-	y := 0
-
-	if button == 6 {
-		// Mouse wheel
-		button = nextByte(stream) - 48
-	}
-
-	scan := bufio.NewScanner(stream)
-	scan.Split(bufio.ScanRunes)
-
-	numStr := ""
-	tokenIdx := 0
-ScanLoop:
-	for scan.Scan() {
-		chr := scan.Text()[0]
-		// Convert read ASCII number into a real number
-		if chr == ';' || chr == 'm' || chr == 'M' {
-			i, _ := strconv.Atoi(numStr)
-			if tokenIdx == 1 {
-				x = i - 1
-			}
-			if tokenIdx == 2 {
-				y = i - 1
-			}
-			numStr = ""
-			tokenIdx += 1
+// Decodes the next event from the input read so far, skipping anything that isn't understood
+func (t *Terminal) decodePending(complete bool) (InputEvent, bool) {
+	for len(t.pending) > 0 {
+		event, size, ok := decodeEvent(t.pending, complete)
+		if size == 0 {
+			break
 		}
-
-		switch chr {
-		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			numStr += string(chr)
-
-		// End of sequence
-		case 'm':
-			if button == 3 {
-				action = MouseMove
-			} else {
-				action = MouseUp
-			}
-			break ScanLoop
-		case 'M':
-			if button == 3 {
-				action = MouseMove
-			} else {
-				action = MouseDown
+		t.pending = t.pending[size:]
+		if ok {
+			if event.EventType == MouseEvent {
+				event.MousePixels = t.currentModes()&mousePixelsMode != 0
 			}
-			break ScanLoop
+			return event, true
 		}
 	}
-
-	return InputEvent{
-		EventType:   MouseEvent,
-		MouseButton: MouseButton(button), // FIXME translate button number
-		MouseAction: action,
-		MouseX:      x,
-		MouseY:      y,
-	}
-}
-
-func peekByte(s *bufio.Reader) byte {
-	return peekBytes(s, 1)[0]
+	return InputEvent{}, false
 }
 
-func peekBytes(s *bufio.Reader, num int) []byte {
-	bytes, err := s.Peek(num)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error peeking %v\n", err)
-		return []byte{}
+// Reads input in the background until reading fails, signalling inputReady whenever
+// there's more to take. It never waits for input to be taken, so it's left waiting
+// only on stdin when nothing is reading events, and carries on for the next reader.
+func (t *Terminal) readInput() {
+	buf := make([]byte, 256)
+	for {
+		n, err := t.stdin.Read(buf)
+		t.inputMux.Lock()
+		t.unread = append(t.unread, buf[:n]...)
+		t.readErr = err
+		t.inputMux.Unlock()
+
+		select {
+		case t.inputReady <- struct{}{}:
+		default:
+		}
+		if err != nil {
+			return
+		}
 	}
-
-	return bytes
 }
 
-func nextRune(s *bufio.Reader) rune {
-	return rune(nextByte(s))
+// Takes everything read so far, along with the error that stopped reading, if it has
+func (t *Terminal) takeInput() ([]byte, error) {
+	t.inputMux.Lock()
+	defer t.inputMux.Unlock()
+	data := t.unread
+	t.unread = nil
+	return data, t.readErr
 }
 
-func nextByte(s *bufio.Reader) byte {
-	b, err := s.ReadByte()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading %v\n", err)
-		return 0
+// How long to wait for the rest of an escape sequence
+func (t *Terminal) EscapeTimeout() time.Duration {
+	if t.escapeTimeout == 0 {
+		return defaultEscapeTimeout
 	}
+	return t.escapeTimeout
+}
 
-	return b
+// Changes how long to wait for the rest of an escape sequence. Slow connections may need longer.
+func (t *Terminal) SetEscapeTimeout(timeout time.Duration) {
+	t.escapeTimeout = timeout
 }
 
-func isCtrlChar(b byte) bool {
-	switch b {
-	case 'D', 'E', 'H', 'M', 'N', 'O', 'P', 'V', 'W', 'X', 'Z', '[', '\\', ']', '^', '_':
-		return true
-	default:
-		return false
+func NewInputEventFromKey(b byte) InputEvent {
+	return InputEvent{
+		EventType: KeyEvent,
+		Key:       rune(b),
 	}
 }
@@ -0,0 +1,315 @@
+package terminal
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Shift, Alt, Ctrl and Meta held down with a key or mouse button.
+// The bits match the modifier parameter xterm sends, minus 1.
+type KeyModifier uint8
+
+const (
+	ModShift KeyModifier = 1 << iota
+	ModAlt
+	ModCtrl
+	ModMeta
+)
+
+// Keys that don't have a character of their own. Most are given values past the end
+// of Unicode, so they can be used in InputEvent.Key alongside ordinary characters.
+const (
+	KeyUp rune = unicode.MaxRune + 1 + iota
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyInsert
+	KeyDelete
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+)
+
+const (
+	KeyEscape    rune = 0x1b
+	KeyTab       rune = '\t'
+	KeyEnter     rune = '\r'
+	KeyBackspace rune = 0x7f
+)
+
+var keyNames = map[rune]string{
+	KeyUp:        "Up",
+	KeyDown:      "Down",
+	KeyLeft:      "Left",
+	KeyRight:     "Right",
+	KeyHome:      "Home",
+	KeyEnd:       "End",
+	KeyPageUp:    "PageUp",
+	KeyPageDown:  "PageDown",
+	KeyInsert:    "Insert",
+	KeyDelete:    "Delete",
+	KeyEscape:    "Escape",
+	KeyTab:       "Tab",
+	KeyEnter:     "Enter",
+	KeyBackspace: "Backspace",
+	' ':          "Space",
+}
+
+// Human readable name of a key, e.g. "Up", "F5" or "a"
+func KeyName(key rune) string {
+	if name, ok := keyNames[key]; ok {
+		return name
+	}
+	if key >= KeyF1 && key <= KeyF12 {
+		return "F" + strconv.Itoa(int(key-KeyF1)+1)
+	}
+	return string(key)
+}
+
+func (m KeyModifier) String() string {
+	names := []string{}
+	for i, name := range []string{"Shift", "Alt", "Ctrl", "Meta"} {
+		if m&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "+")
+}
+
+// Keys sent as CSI <number> ~
+var tildeKeys = map[int]rune{
+	1: KeyHome, 2: KeyInsert, 3: KeyDelete, 4: KeyEnd, 5: KeyPageUp, 6: KeyPageDown, 7: KeyHome, 8: KeyEnd,
+	11: KeyF1, 12: KeyF2, 13: KeyF3, 14: KeyF4, 15: KeyF5,
+	17: KeyF6, 18: KeyF7, 19: KeyF8, 20: KeyF9, 21: KeyF10, 23: KeyF11, 24: KeyF12,
+}
+
+// Keys sent as CSI <letter> or SS3 <letter>
+var letterKeys = map[byte]rune{
+	'A': KeyUp, 'B': KeyDown, 'C': KeyRight, 'D': KeyLeft, 'H': KeyHome, 'F': KeyEnd,
+	'P': KeyF1, 'Q': KeyF2, 'R': KeyF3, 'S': KeyF4,
+}
+
+func newKeyEvent(key rune, modifiers KeyModifier) InputEvent {
+	return InputEvent{
+		EventType: KeyEvent,
+		Key:       key,
+		Modifiers: modifiers,
+	}
+}
+
+// Whether a key was pressed, held down or let go
+type KeyAction uint8
+
+const (
+	KeyPress KeyAction = iota
+	KeyRepeat
+	KeyRelease
+)
+
+// Modifiers from an xterm modifier parameter, which is 1 plus the modifier bits.
+// The kitty keyboard protocol follows it with the action, e.g. 5:3 for Ctrl released.
+func parseModifierParam(param string) (KeyModifier, KeyAction) {
+	parts := strings.SplitN(param, ":", 2)
+	modifiers := KeyModifier(0)
+	if value, err := strconv.Atoi(parts[0]); err == nil && value > 1 {
+		// Leave out Hyper, Caps Lock and Num Lock
+		modifiers = KeyModifier(value-1) & (ModShift | ModAlt | ModCtrl | ModMeta)
+	}
+
+	action := KeyPress
+	if len(parts) > 1 {
+		switch parts[1] {
+		case "2":
+			action = KeyRepeat
+		case "3":
+			action = KeyRelease
+		}
+	}
+	return modifiers, action
+}
+
+// Sent around pasted text in bracketed paste mode
+const (
+	pasteStart = "\x1b[200~"
+	pasteEnd   = "\x1b[201~"
+)
+
+// Decodes the first event in some input.
+// Returns the number of bytes it used, or 0 if the input ends part way through an event.
+// Once complete is true no more input is coming, e.g. after a timeout, so whatever
+// is there is decoded as best it can be. Events that aren't understood use up their
+// bytes but return ok as false.
+func decodeEvent(data []byte, complete bool) (event InputEvent, size int, ok bool) {
+	if len(data) == 0 {
+		return event, 0, false
+	}
+
+	b := data[0]
+	switch {
+	case b == 0x1b:
+		return decodeEscape(data, complete)
+
+	case b == '\r' || b == '\n':
+		return newKeyEvent(KeyEnter, 0), 1, true
+	case b == '\t':
+		return newKeyEvent(KeyTab, 0), 1, true
+	case b == 0x7f || b == 0x08:
+		return newKeyEvent(KeyBackspace, 0), 1, true
+	case b == 0:
+		return newKeyEvent(' ', ModCtrl), 1, true
+	case b < 0x1b:
+		// Ctrl + a letter
+		return newKeyEvent(rune('a'+b-1), ModCtrl), 1, true
+	case b < 0x20:
+		// Ctrl + one of \ ] ^ _
+		return newKeyEvent(rune(b+0x40), ModCtrl), 1, true
+	}
+
+	if !utf8.FullRune(data) && !complete {
+		return event, 0, false
+	}
+	r, size := utf8.DecodeRune(data)
+	return newKeyEvent(r, 0), size, true
+}
+
+// Decodes input starting with an escape: a CSI or SS3 sequence, Alt with a key, or Escape on its own
+func decodeEscape(data []byte, complete bool) (event InputEvent, size int, ok bool) {
+	if len(data) == 1 {
+		if !complete {
+			return event, 0, false
+		}
+		return newKeyEvent(KeyEscape, 0), 1, true
+	}
+
+	if data[1] == '[' || data[1] == 'O' {
+		if data[1] == '[' {
+			event, size, ok = decodeCSI(data)
+		} else {
+			event, size, ok = decodeSS3(data)
+		}
+		// Pastes are waited for, however long they take
+		if size > 0 || !complete || bytes.HasPrefix(data, []byte(pasteStart)) {
+			return event, size, ok
+		}
+		// Nothing else came, so it was Alt with [ or O
+	}
+
+	// Alt is sent as an escape before the key
+	if data[1] == 0x1b {
+		return newKeyEvent(KeyEscape, ModAlt), 2, true
+	}
+	event, size, ok = decodeEvent(data[1:], complete)
+	if size == 0 {
+		return event, 0, false
+	}
+	event.Modifiers |= ModAlt
+	return event, size + 1, ok
+}
+
+// Decodes ESC [ <parameters> <intermediates> <final byte>, including kitty's CSI u keys
+func decodeCSI(data []byte) (event InputEvent, size int, ok bool) {
+	if len(data) >= 3 && data[2] == 'M' {
+		// X10 mouse, followed by 3 raw bytes
+		if len(data) < 6 {
+			return event, 0, false
+		}
+		return decodeX10Mouse(data[3:6]), 6, true
+	}
+
+	end := 2
+	for end < len(data) && data[end] >= 0x20 && data[end] < 0x40 {
+		end++
+	}
+	if end == len(data) {
+		return event, 0, false
+	}
+	size = end + 1
+	params, final := string(data[2:end]), data[end]
+
+	if strings.HasPrefix(params, "<") && (final == 'M' || final == 'm') {
+		event, ok = decodeSGRMouse(params[1:], final)
+		return event, size, ok
+	}
+
+	switch {
+	case params == "" && (final == 'I' || final == 'O'):
+		return InputEvent{EventType: FocusEvent, Focused: final == 'I'}, size, true
+	case params == "200" && final == '~':
+		// Everything up to the end marker was pasted
+		end := bytes.Index(data[size:], []byte(pasteEnd))
+		if end < 0 {
+			return event, 0, false
+		}
+		return InputEvent{EventType: PasteEvent, Text: string(data[size : size+end])}, size + end + len(pasteEnd), true
+	}
+
+	if final == 'u' {
+		event, ok = decodeKittyKey(params)
+		return event, size, ok
+	}
+
+	fields := strings.Split(params, ";")
+	modifiers, action := KeyModifier(0), KeyPress
+	if len(fields) > 1 {
+		modifiers, action = parseModifierParam(fields[1])
+	}
+
+	switch final {
+	case '~':
+		number, _ := strconv.Atoi(fields[0])
+		if key, found := tildeKeys[number]; found {
+			event = newKeyEvent(key, modifiers)
+			event.KeyAction = action
+			return event, size, true
+		}
+	case 'Z':
+		return newKeyEvent(KeyTab, ModShift), size, true
+	default:
+		if key, found := letterKeys[final]; found {
+			event = newKeyEvent(key, modifiers)
+			event.KeyAction = action
+			return event, size, true
+		}
+	}
+
+	return event, size, false
+}
+
+// Decodes ESC O <final byte>, sent by the cursor keys in application mode and F1 to F4.
+// Some terminals put a modifier before the final byte.
+func decodeSS3(data []byte) (event InputEvent, size int, ok bool) {
+	end := 2
+	for end < len(data) && data[end] >= '0' && data[end] <= '9' {
+		end++
+	}
+	if end == len(data) {
+		return event, 0, false
+	}
+	size = end + 1
+
+	if key, found := letterKeys[data[end]]; found {
+		modifiers, _ := parseModifierParam(string(data[2:end]))
+		return newKeyEvent(key, modifiers), size, true
+	}
+	if data[end] == 'M' {
+		// Enter on the keypad
+		return newKeyEvent(KeyEnter, 0), size, true
+	}
+	return event, size, false
+}
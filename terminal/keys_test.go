@@ -0,0 +1,140 @@
+package terminal
+
+import (
+	"bufio"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	tests := []struct {
+		input     string
+		key       rune
+		modifiers KeyModifier
+		size      int
+	}{
+		{"a", 'a', 0, 1},
+		{"A", 'A', 0, 1},
+		{"ab", 'a', 0, 1},
+		{"é", 'é', 0, 2},
+		{"漢字", '漢', 0, 3},
+		{"\r", KeyEnter, 0, 1},
+		{"\t", KeyTab, 0, 1},
+		{"\x7f", KeyBackspace, 0, 1},
+		{"\x08", KeyBackspace, 0, 1},
+		{"\x01", 'a', ModCtrl, 1},
+		{"\x17", 'w', ModCtrl, 1},
+		{"\x00", ' ', ModCtrl, 1},
+		{"\x1d", ']', ModCtrl, 1},
+
+		// Cursor keys, in normal and application mode
+		{"\x1b[A", KeyUp, 0, 3},
+		{"\x1b[B", KeyDown, 0, 3},
+		{"\x1b[C", KeyRight, 0, 3},
+		{"\x1b[D", KeyLeft, 0, 3},
+		{"\x1bOA", KeyUp, 0, 3},
+		{"\x1bOD", KeyLeft, 0, 3},
+		{"\x1b[1;2A", KeyUp, ModShift, 6},
+		{"\x1b[1;5C", KeyRight, ModCtrl, 6},
+		{"\x1b[1;3D", KeyLeft, ModAlt, 6},
+		{"\x1b[1;8B", KeyDown, ModShift | ModAlt | ModCtrl, 6},
+
+		{"\x1b[H", KeyHome, 0, 3},
+		{"\x1b[F", KeyEnd, 0, 3},
+		{"\x1b[1~", KeyHome, 0, 4},
+		{"\x1b[4~", KeyEnd, 0, 4},
+		{"\x1b[2~", KeyInsert, 0, 4},
+		{"\x1b[3~", KeyDelete, 0, 4},
+		{"\x1b[3;5~", KeyDelete, ModCtrl, 6},
+		{"\x1b[5~", KeyPageUp, 0, 4},
+		{"\x1b[6~", KeyPageDown, 0, 4},
+
+		{"\x1bOP", KeyF1, 0, 3},
+		{"\x1bOS", KeyF4, 0, 3},
+		{"\x1b[1;2P", KeyF1, ModShift, 6},
+		{"\x1b[15~", KeyF5, 0, 5},
+		{"\x1b[17~", KeyF6, 0, 5},
+		{"\x1b[21~", KeyF10, 0, 5},
+		{"\x1b[24;5~", KeyF12, ModCtrl, 7},
+		{"\x1b[Z", KeyTab, ModShift, 3},
+
+		// Alt is sent as a leading escape
+		{"\x1ba", 'a', ModAlt, 2},
+		{"\x1b\x01", 'a', ModAlt | ModCtrl, 2},
+		{"\x1b\x1b", KeyEscape, ModAlt, 2},
+		{"\x1b\x1b[A", KeyEscape, ModAlt, 2},
+	}
+
+	for _, test := range tests {
+		event, size, ok := decodeEvent([]byte(test.input), false)
+		if !ok || event.EventType != KeyEvent || event.Key != test.key || event.Modifiers != test.modifiers || size != test.size {
+			t.Errorf("Decoding %q: expected %s %v using %d bytes, got %s %v using %d bytes (ok %v)",
+				test.input, KeyName(test.key), test.modifiers, test.size, KeyName(event.Key), event.Modifiers, size, ok)
+		}
+	}
+}
+
+func TestDecodeIncompleteKeys(t *testing.T) {
+	for _, input := range []string{"\x1b", "\x1b[", "\x1b[1;5", "\x1bO", "\xc3", "\xe6\xbc", "\x1b[M\x20"} {
+		if _, size, _ := decodeEvent([]byte(input), false); size != 0 {
+			t.Errorf("Expected %q to wait for more input, but it used %d bytes", input, size)
+		}
+	}
+
+	// After a timeout, whatever has arrived is a key press
+	tests := []struct {
+		input     string
+		key       rune
+		modifiers KeyModifier
+		size      int
+	}{
+		{"\x1b", KeyEscape, 0, 1},
+		{"\x1b[", '[', ModAlt, 2},
+		{"\x1bO", 'O', ModAlt, 2},
+		{"\xc3", 0xfffd, 0, 1},
+	}
+	for _, test := range tests {
+		event, size, ok := decodeEvent([]byte(test.input), true)
+		if !ok || event.Key != test.key || event.Modifiers != test.modifiers || size != test.size {
+			t.Errorf("Decoding %q after a timeout: expected %s %v, got %s %v using %d bytes", test.input, KeyName(test.key), test.modifiers, KeyName(event.Key), event.Modifiers, size)
+		}
+	}
+}
+
+func TestDecodeUnknownSequence(t *testing.T) {
+	event, size, ok := decodeEvent([]byte("\x1b[99xa"), false)
+	if ok || size != 5 {
+		t.Errorf("Expected an unknown sequence to be skipped, got %+v using %d bytes", event, size)
+	}
+}
+
+func TestNextEvent(t *testing.T) {
+	reader, writer := io.Pipe()
+	term := &Terminal{stdin: *bufio.NewReader(reader)}
+	term.SetEscapeTimeout(20 * time.Millisecond)
+
+	go func() {
+		// A sequence split over several reads, then Escape on its own
+		writer.Write([]byte("\x1b["))
+		time.Sleep(5 * time.Millisecond)
+		writer.Write([]byte("1;5A\xe2\x82"))
+		time.Sleep(5 * time.Millisecond)
+		writer.Write([]byte("\xac"))
+		writer.Write([]byte("\x1b"))
+		time.Sleep(50 * time.Millisecond)
+		writer.Write([]byte("q"))
+	}()
+
+	expected := []InputEvent{
+		newKeyEvent(KeyUp, ModCtrl),
+		newKeyEvent('€', 0),
+		newKeyEvent(KeyEscape, 0),
+		newKeyEvent('q', 0),
+	}
+	for _, want := range expected {
+		if got := term.NextEvent(); got != want {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package terminal
+
+import (
+	"sync"
+	"time"
+)
+
+// Keeps track of which keys are held down, from the key events it's given.
+// Safe to update from one goroutine while another checks it.
+//
+// Terminals without the kitty keyboard protocol never say when a key is let go,
+// so a key without a release is counted as held for Timeout after it was last
+// pressed or repeated. A Timeout of 0 waits for the release.
+type KeyState struct {
+	Timeout time.Duration
+	down    map[rune]time.Time
+	now     func() time.Time
+	mux     sync.Mutex
+}
+
+func NewKeyState(timeout time.Duration) *KeyState {
+	return &KeyState{
+		Timeout: timeout,
+		down:    map[rune]time.Time{},
+		now:     time.Now,
+	}
+}
+
+// Notes a key being pressed, repeated or released. Other events are ignored.
+func (k *KeyState) Update(event InputEvent) {
+	if event.EventType != KeyEvent {
+		return
+	}
+
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	if event.KeyAction == KeyRelease {
+		delete(k.down, event.Key)
+	} else {
+		k.down[event.Key] = k.now()
+	}
+}
+
+// Checks if a key is held down
+func (k *KeyState) IsDown(key rune) bool {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	return k.isDown(key)
+}
+
+func (k *KeyState) isDown(key rune) bool {
+	pressed, ok := k.down[key]
+	if !ok {
+		return false
+	}
+	if k.Timeout > 0 && k.now().Sub(pressed) > k.Timeout {
+		delete(k.down, key)
+		return false
+	}
+	return true
+}
+
+// Every key held down, in no particular order
+func (k *KeyState) Down() []rune {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+
+	keys := []rune{}
+	for key := range k.down {
+		if k.isDown(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Lets go of every key, e.g. when the terminal loses focus and releases won't arrive
+func (k *KeyState) Reset() {
+	k.mux.Lock()
+	k.down = map[rune]time.Time{}
+	k.mux.Unlock()
+}
@@ -0,0 +1,110 @@
+package terminal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Progressive enhancements of the kitty keyboard protocol.
+// See https://sw.kovidgoyal.net/kitty/keyboard-protocol/
+type KittyKeyboardFlags uint8
+
+const (
+	// Send keys that are ambiguous, like Escape and Alt combinations, as CSI u sequences
+	KittyDisambiguate KittyKeyboardFlags = 1 << iota
+	// Report key repeats and releases, not just presses
+	KittyReportEvents
+	// Report shifted and base layout keys too
+	KittyReportAlternates
+	// Send every key as an escape sequence, even ones that type text, so their releases are reported
+	KittyReportAllKeys
+	// Include the text a key types
+	KittyReportText
+)
+
+// Flags needed to know which keys are held down
+const KittyKeyState = KittyDisambiguate | KittyReportEvents | KittyReportAllKeys
+
+// Asks the terminal if it supports the kitty keyboard protocol.
+// Call it before anything else reads input, as it reads the terminal's answer from stdin.
+func (t *Terminal) DetectKittyKeyboard() bool {
+	_, ok := kittyKeyboardFlags(t.query("\x1b[?u", queryTimeout))
+	return ok
+}
+
+// Finds the report of the current keyboard flags: CSI ? <flags> u
+func kittyKeyboardFlags(reports []queryReport) (KittyKeyboardFlags, bool) {
+	for _, report := range reports {
+		if report.Final != 'u' {
+			continue
+		}
+		flags, err := strconv.Atoi(report.Params)
+		if err == nil {
+			return KittyKeyboardFlags(flags), true
+		}
+	}
+	return 0, false
+}
+
+// Turns on kitty keyboard enhancements. Terminals that don't support them ignore this.
+func (t *Terminal) EnableKittyKeyboard(flags KittyKeyboardFlags) {
+	t.CSI(">%du", flags)
+	t.setKittyKeyboard(flags)
+}
+
+// Goes back to how the keyboard worked before EnableKittyKeyboard
+func (t *Terminal) DisableKittyKeyboard() {
+	if t.KittyKeyboard() != 0 {
+		t.CSI("<u")
+		t.setKittyKeyboard(0)
+	}
+}
+
+// Enhancements turned on with EnableKittyKeyboard
+func (t *Terminal) KittyKeyboard() KittyKeyboardFlags {
+	t.modesMux.Lock()
+	defer t.modesMux.Unlock()
+	return t.kittyFlags
+}
+
+func (t *Terminal) setKittyKeyboard(flags KittyKeyboardFlags) {
+	t.modesMux.Lock()
+	defer t.modesMux.Unlock()
+	t.kittyFlags = flags
+}
+
+// Keys with their own codes in CSI <code> u
+var kittyKeys = map[int]rune{
+	9: KeyTab, 13: KeyEnter, 27: KeyEscape, 127: KeyBackspace,
+	// Keypad
+	57399: '0', 57400: '1', 57401: '2', 57402: '3', 57403: '4',
+	57404: '5', 57405: '6', 57406: '7', 57407: '8', 57408: '9',
+	57409: '.', 57410: '/', 57411: '*', 57412: '-', 57413: '+', 57414: KeyEnter, 57415: '=',
+	57417: KeyLeft, 57418: KeyRight, 57419: KeyUp, 57420: KeyDown, 57421: KeyPageUp,
+	57422: KeyPageDown, 57423: KeyHome, 57424: KeyEnd, 57425: KeyInsert, 57426: KeyDelete,
+}
+
+// Decodes the parameters of CSI <code>[:<shifted>[:<base>]] ; <modifiers>[:<action>] [; <text>] u
+func decodeKittyKey(params string) (event InputEvent, ok bool) {
+	fields := strings.Split(params, ";")
+	codes := strings.Split(fields[0], ":")
+	code, err := strconv.Atoi(codes[0])
+	if err != nil {
+		return event, false
+	}
+
+	key, found := kittyKeys[code]
+	if !found {
+		// Other keys in the private use area are modifiers and media keys
+		if code >= 57344 && code <= 63743 {
+			return event, false
+		}
+		key = rune(code)
+	}
+
+	modifiers, action := KeyModifier(0), KeyPress
+	if len(fields) > 1 {
+		modifiers, action = parseModifierParam(fields[1])
+	}
+	return InputEvent{EventType: KeyEvent, Key: key, Modifiers: modifiers, KeyAction: action}, true
+}
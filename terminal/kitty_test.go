@@ -0,0 +1,112 @@
+package terminal
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDecodeKittyKeys(t *testing.T) {
+	tests := []struct {
+		input     string
+		key       rune
+		modifiers KeyModifier
+		action    KeyAction
+	}{
+		{"\x1b[119u", 'w', 0, KeyPress},
+		{"\x1b[119;1:1u", 'w', 0, KeyPress},
+		{"\x1b[119;1:2u", 'w', 0, KeyRepeat},
+		{"\x1b[119;1:3u", 'w', 0, KeyRelease},
+		{"\x1b[119:87;2u", 'w', ModShift, KeyPress},
+		{"\x1b[99;5u", 'c', ModCtrl, KeyPress},
+		{"\x1b[27u", KeyEscape, 0, KeyPress},
+		{"\x1b[13;3u", KeyEnter, ModAlt, KeyPress},
+		{"\x1b[127;1:3u", KeyBackspace, 0, KeyRelease},
+		{"\x1b[57399u", '0', 0, KeyPress},
+		{"\x1b[57419;1:3u", KeyUp, 0, KeyRelease},
+		// Caps Lock is left out of the modifiers
+		{"\x1b[97;65u", 'a', 0, KeyPress},
+		{"\x1b[97;66:2u", 'a', ModShift, KeyRepeat},
+		// Legacy sequences with an action
+		{"\x1b[1;1:3A", KeyUp, 0, KeyRelease},
+		{"\x1b[1;5:2D", KeyLeft, ModCtrl, KeyRepeat},
+		{"\x1b[3;1:3~", KeyDelete, 0, KeyRelease},
+	}
+
+	for _, test := range tests {
+		event, size, ok := decodeEvent([]byte(test.input), false)
+		if !ok || size != len(test.input) || event.Key != test.key || event.Modifiers != test.modifiers || event.KeyAction != test.action {
+			t.Errorf("Decoding %q: expected %s %v %v, got %s %v %v (ok %v)", test.input, KeyName(test.key), test.modifiers, test.action, KeyName(event.Key), event.Modifiers, event.KeyAction, ok)
+		}
+	}
+
+	// Left Shift on its own
+	if _, size, ok := decodeEvent([]byte("\x1b[57441;2u"), false); ok || size != 10 {
+		t.Errorf("Expected modifier keys to be skipped")
+	}
+}
+
+func TestKittyKeyboardFlags(t *testing.T) {
+	reports, answered, _ := parseQueryReports([]byte("\x1b[?11u\x1b[?62c"))
+	flags, ok := kittyKeyboardFlags(reports)
+	if !answered || !ok || flags != KittyKeyState {
+		t.Errorf("Expected flags %d, got %d (ok %v)", KittyKeyState, flags, ok)
+	}
+
+	reports, _, _ = parseQueryReports([]byte("\x1b[?62c"))
+	if _, ok := kittyKeyboardFlags(reports); ok {
+		t.Errorf("Expected no support without a report")
+	}
+}
+
+func TestKeyState(t *testing.T) {
+	now := time.Unix(0, 0)
+	keys := NewKeyState(0)
+	keys.now = func() time.Time { return now }
+
+	keys.Update(InputEvent{EventType: KeyEvent, Key: 'w'})
+	keys.Update(InputEvent{EventType: KeyEvent, Key: KeyLeft})
+	keys.Update(InputEvent{EventType: MouseEvent})
+	now = now.Add(time.Hour)
+	keys.Update(InputEvent{EventType: KeyEvent, Key: 'w', KeyAction: KeyRepeat})
+
+	down := keys.Down()
+	sort.Slice(down, func(i, j int) bool { return down[i] < down[j] })
+	if len(down) != 2 || down[0] != 'w' || down[1] != KeyLeft {
+		t.Errorf("Expected w and Left to be down, got %q", down)
+	}
+
+	keys.Update(InputEvent{EventType: KeyEvent, Key: 'w', KeyAction: KeyRelease})
+	if keys.IsDown('w') || !keys.IsDown(KeyLeft) {
+		t.Errorf("Expected only Left to be down after releasing w")
+	}
+
+	keys.Reset()
+	if len(keys.Down()) != 0 {
+		t.Errorf("Expected no keys down after a reset")
+	}
+}
+
+func TestKeyStateTimeout(t *testing.T) {
+	now := time.Unix(0, 0)
+	keys := NewKeyState(100 * time.Millisecond)
+	keys.now = func() time.Time { return now }
+
+	keys.Update(InputEvent{EventType: KeyEvent, Key: 'a'})
+	now = now.Add(80 * time.Millisecond)
+	if !keys.IsDown('a') {
+		t.Errorf("Expected a to be held until the timeout")
+	}
+
+	// Repeats keep it held
+	keys.Update(InputEvent{EventType: KeyEvent, Key: 'a'})
+	now = now.Add(80 * time.Millisecond)
+	if !keys.IsDown('a') {
+		t.Errorf("Expected a repeat to keep a held")
+	}
+
+	now = now.Add(80 * time.Millisecond)
+	if keys.IsDown('a') {
+		t.Errorf("Expected a to be let go after the timeout")
+	}
+}
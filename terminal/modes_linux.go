@@ -1,3 +1,4 @@
+//go:build linux
 // +build linux
 
 package terminal
@@ -122,10 +123,12 @@ func (t *Terminal) EnableMouse() {
 	t.CSI("?1002h") // Enable "button" Xterm mouse events
 	//t.CSI("?1015h") // Enable urxvt extended mouse positions (for > 223 cells)
 	t.CSI("?1006h") // Enable SGR extended mouse positions (for > 223 cells)
+	t.addModes(mouseMode)
 }
 
 func (t *Terminal) EnableMouseMove() {
 	t.CSI("?1003h") // Enable "any" Xterm mouse events (i.e. mouse move without buttons)
+	t.addModes(mouseMoveMode)
 }
 
 // Disables mouse position tracking
@@ -134,7 +137,9 @@ func (t *Terminal) DisableMouse() {
 	//t.CSI("?1015h") // Disable urxvt extended mouse positions
 	t.CSI("?1002l") // Disable "button" Xterm mouse events
 	t.CSI("?1000l") // Disable VT200
+	t.removeModes(mouseMode)
 }
 func (t *Terminal) DisableMouseMove() {
 	t.CSI("?1003l") // Disable "any" Xterm mouse events
+	t.removeModes(mouseMoveMode)
 }
@@ -0,0 +1,115 @@
+package terminal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Bits of the button code in mouse reports.
+// See https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h2-Mouse-Tracking
+const (
+	mouseButtonBits = 0x03
+	mouseShift      = 0x04
+	mouseAlt        = 0x08
+	mouseCtrl       = 0x10
+	mouseMotion     = 0x20
+	mouseWheel      = 0x40
+	mouseExtra      = 0x80
+)
+
+// Buttons by their number in mouse reports: 0-3, then 4-7 for the wheel and 8-11 for extra buttons
+var mouseButtons = map[int]MouseButton{
+	0: MouseLeft, 1: MouseMiddle, 2: MouseRight, 3: MouseNoButton,
+	4: MouseScrollUp, 5: MouseScrollDown, 6: MouseScrollLeft, 7: MouseScrollRight,
+	8: MouseBack, 9: MouseForward,
+}
+
+// Reports positions in pixels instead of cells, for terminals that support SGR pixel mode.
+// Only takes effect while the mouse is enabled.
+func (t *Terminal) EnableMousePixels() {
+	t.CSI("?1016h")
+	t.addModes(mousePixelsMode)
+}
+
+func (t *Terminal) DisableMousePixels() {
+	t.CSI("?1016l")
+	t.removeModes(mousePixelsMode)
+}
+
+// Decodes the button code shared by X10 and SGR mouse reports.
+// X10 reports releases as button 3, SGR reports them with a different final byte.
+func decodeMouseCode(code int, released bool) (event InputEvent, ok bool) {
+	number := code & mouseButtonBits
+	if code&mouseWheel != 0 {
+		number += 4
+	}
+	if code&mouseExtra != 0 {
+		number += 8
+	}
+	button, ok := mouseButtons[number]
+	if !ok {
+		return event, false
+	}
+
+	event = InputEvent{
+		EventType:   MouseEvent,
+		MouseButton: button,
+	}
+	if code&mouseShift != 0 {
+		event.Modifiers |= ModShift
+	}
+	if code&mouseAlt != 0 {
+		event.Modifiers |= ModAlt
+	}
+	if code&mouseCtrl != 0 {
+		event.Modifiers |= ModCtrl
+	}
+
+	switch {
+	case number >= 4 && number <= 7:
+		event.MouseAction = MouseWheel
+	case code&mouseMotion != 0 && button == MouseNoButton:
+		event.MouseAction = MouseMove
+	case code&mouseMotion != 0:
+		event.MouseAction = MouseDrag
+	case released || button == MouseNoButton:
+		event.MouseAction = MouseUp
+	default:
+		event.MouseAction = MouseDown
+	}
+	return event, true
+}
+
+// Decodes the 3 bytes after CSI M: the button code and position, each plus 32
+func decodeX10Mouse(data []byte) InputEvent {
+	event, ok := decodeMouseCode(int(data[0])-32, false)
+	if !ok {
+		event = InputEvent{EventType: MouseEvent, MouseButton: MouseNoButton, MouseAction: MouseUp}
+	}
+	// Positions start at 1
+	event.MouseX = int(data[1]) - 33
+	event.MouseY = int(data[2]) - 33
+	return event
+}
+
+// Decodes the parameters of CSI < code ; x ; y M, or m for a release
+func decodeSGRMouse(params string, final byte) (event InputEvent, ok bool) {
+	fields := strings.Split(params, ";")
+	if len(fields) != 3 {
+		return event, false
+	}
+	values := [3]int{}
+	for i, field := range fields {
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			return event, false
+		}
+		values[i] = value
+	}
+
+	event, ok = decodeMouseCode(values[0], final == 'm')
+	// Positions start at 1
+	event.MouseX = values[1] - 1
+	event.MouseY = values[2] - 1
+	return event, ok
+}
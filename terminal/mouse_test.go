@@ -0,0 +1,80 @@
+package terminal
+
+import (
+	"testing"
+)
+
+func TestDecodeMouse(t *testing.T) {
+	tests := []struct {
+		input     string
+		button    MouseButton
+		action    MouseAction
+		modifiers KeyModifier
+		x, y      int
+	}{
+		// SGR
+		{"\x1b[<0;10;5M", MouseLeft, MouseDown, 0, 9, 4},
+		{"\x1b[<0;10;5m", MouseLeft, MouseUp, 0, 9, 4},
+		{"\x1b[<1;1;1M", MouseMiddle, MouseDown, 0, 0, 0},
+		{"\x1b[<2;300;200M", MouseRight, MouseDown, 0, 299, 199},
+		{"\x1b[<2;300;200m", MouseRight, MouseUp, 0, 299, 199},
+		{"\x1b[<32;4;4M", MouseLeft, MouseDrag, 0, 3, 3},
+		{"\x1b[<34;4;4M", MouseRight, MouseDrag, 0, 3, 3},
+		{"\x1b[<33;4;4M", MouseMiddle, MouseDrag, 0, 3, 3},
+		{"\x1b[<35;7;8M", MouseNoButton, MouseMove, 0, 6, 7},
+		{"\x1b[<64;2;3M", MouseScrollUp, MouseWheel, 0, 1, 2},
+		{"\x1b[<65;2;3M", MouseScrollDown, MouseWheel, 0, 1, 2},
+		{"\x1b[<66;2;3M", MouseScrollLeft, MouseWheel, 0, 1, 2},
+		{"\x1b[<67;2;3M", MouseScrollRight, MouseWheel, 0, 1, 2},
+		{"\x1b[<128;2;3M", MouseBack, MouseDown, 0, 1, 2},
+		{"\x1b[<129;2;3m", MouseForward, MouseUp, 0, 1, 2},
+		{"\x1b[<4;1;1M", MouseLeft, MouseDown, ModShift, 0, 0},
+		{"\x1b[<8;1;1M", MouseLeft, MouseDown, ModAlt, 0, 0},
+		{"\x1b[<16;1;1M", MouseLeft, MouseDown, ModCtrl, 0, 0},
+		{"\x1b[<54;1;1M", MouseRight, MouseDrag, ModShift | ModCtrl, 0, 0},
+		{"\x1b[<80;1;1M", MouseScrollUp, MouseWheel, ModCtrl, 0, 0},
+
+		// X10, with everything plus 32
+		{"\x1b[M !\x22", MouseLeft, MouseDown, 0, 0, 1},
+		{"\x1b[M\x22\x2a\x2b", MouseRight, MouseDown, 0, 9, 10},
+		{"\x1b[M#!!", MouseNoButton, MouseUp, 0, 0, 0},
+		{"\x1b[M@!!", MouseLeft, MouseDrag, 0, 0, 0},
+		{"\x1b[MC!!", MouseNoButton, MouseMove, 0, 0, 0},
+		{"\x1b[M`!!", MouseScrollUp, MouseWheel, 0, 0, 0},
+		{"\x1b[Ma!!", MouseScrollDown, MouseWheel, 0, 0, 0},
+		{"\x1b[M0!!", MouseLeft, MouseDown, ModCtrl, 0, 0},
+		{"\x1b[M\x3f\xff\xff", MouseNoButton, MouseUp, ModShift | ModAlt | ModCtrl, 222, 222},
+	}
+
+	for _, test := range tests {
+		event, size, ok := decodeEvent([]byte(test.input), false)
+		if !ok || size != len(test.input) || event.EventType != MouseEvent {
+			t.Errorf("Decoding %q: expected a mouse event using %d bytes, got %+v using %d bytes (ok %v)", test.input, len(test.input), event, size, ok)
+			continue
+		}
+		if event.MouseButton != test.button || event.MouseAction != test.action || event.Modifiers != test.modifiers || event.MouseX != test.x || event.MouseY != test.y {
+			t.Errorf("Decoding %q: expected button %d action %d %v at %d,%d, got button %d action %d %v at %d,%d",
+				test.input, test.button, test.action, test.modifiers, test.x, test.y,
+				event.MouseButton, event.MouseAction, event.Modifiers, event.MouseX, event.MouseY)
+		}
+	}
+}
+
+func TestDecodeBadMouse(t *testing.T) {
+	for _, input := range []string{"\x1b[<0;1M", "\x1b[<1;;1M", "\x1b[<1;1;1;1M"} {
+		if _, size, ok := decodeEvent([]byte(input), false); ok || size != len(input) {
+			t.Errorf("Expected %q to be skipped, got ok %v using %d bytes", input, ok, size)
+		}
+	}
+}
+
+func TestMousePixels(t *testing.T) {
+	term, _ := newRecordedTerminal()
+	term.EnableMousePixels()
+	term.pending = []byte("\x1b[<0;640;480M")
+
+	event, ok := term.decodePending(false)
+	if !ok || !event.MousePixels || event.MouseX != 639 || event.MouseY != 479 {
+		t.Errorf("Expected a click at pixel 639,479, got %+v", event)
+	}
+}
@@ -0,0 +1,224 @@
+package terminal
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Terminal modes that have been turned on, so Restore can turn them off again
+type terminalModes uint8
+
+const (
+	altScreenMode terminalModes = 1 << iota
+	hiddenCursorMode
+	mouseMode
+	mouseMoveMode
+	mousePixelsMode
+	focusEventsMode
+	bracketedPasteMode
+)
+
+func (t *Terminal) currentModes() terminalModes {
+	t.modesMux.Lock()
+	defer t.modesMux.Unlock()
+	return t.modes
+}
+
+func (t *Terminal) addModes(modes terminalModes) {
+	t.modesMux.Lock()
+	defer t.modesMux.Unlock()
+	t.modes |= modes
+}
+
+func (t *Terminal) removeModes(modes terminalModes) {
+	t.modesMux.Lock()
+	defer t.modesMux.Unlock()
+	t.modes &^= modes
+}
+
+// Remembers the terminal's settings, for Restore to put back. NewTerminal calls this,
+// so it only needs calling again if something else changes the settings later.
+func (t *Terminal) SaveState() {
+	termios := t.TermIOs()
+	t.savedTermios = &termios
+}
+
+// Puts the terminal back how it was found: turns off every mode that was turned on,
+// and restores the settings saved by SaveState. Safe to call more than once.
+func (t *Terminal) Restore() {
+	// Drop any frame that was being drawn
+	t.frameDepth = 0
+	t.frame.Reset()
+
+	modes := t.currentModes()
+	t.DisableKittyKeyboard()
+	if modes&bracketedPasteMode != 0 {
+		t.DisableBracketedPaste()
+	}
+	if modes&focusEventsMode != 0 {
+		t.DisableFocusEvents()
+	}
+	if modes&mousePixelsMode != 0 {
+		t.DisableMousePixels()
+	}
+	if modes&mouseMoveMode != 0 {
+		t.DisableMouseMove()
+	}
+	if modes&mouseMode != 0 {
+		t.DisableMouse()
+	}
+	if modes != 0 {
+		t.CSI("0m")
+	}
+	if modes&hiddenCursorMode != 0 {
+		t.ShowCursor()
+	}
+	if modes&altScreenMode != 0 {
+		t.MainScreen()
+	}
+	t.Flush()
+
+	if t.savedTermios != nil {
+		t.SetTermIOs(*t.savedTermios)
+	}
+}
+
+// Puts the terminal back how it was found and stops the program, like Ctrl+Z normally does.
+// Resume sets everything up again once the program is continued.
+func (t *Terminal) Suspend() {
+	t.suspended = &suspendedState{
+		termios:    t.TermIOs(),
+		modes:      t.currentModes(),
+		kittyFlags: t.KittyKeyboard(),
+	}
+	t.Restore()
+	stopProcess()
+}
+
+// Stops the process once Suspend has restored the terminal. Swapped out in tests.
+var stopProcess = func() {
+	syscall.Kill(syscall.Getpid(), syscall.SIGSTOP)
+}
+
+// Sends SIGTSTP for Ctrl+Z, which comes as a key instead of a signal when every key is
+// reported, so Guard suspends the same as it would without. Returns false for other keys.
+func SuspendKey(event InputEvent) bool {
+	if event.EventType != KeyEvent || event.KeyAction != KeyPress || event.Key != 'z' || event.Modifiers&ModCtrl == 0 {
+		return false
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGTSTP)
+	return true
+}
+
+// What the terminal was like before Suspend, so Resume can put it back
+type suspendedState struct {
+	termios    syscall.Termios
+	modes      terminalModes
+	kittyFlags KittyKeyboardFlags
+}
+
+// Turns the settings and modes from before Suspend back on. The screen is cleared, so
+// everything needs drawing again. Returns false if the terminal wasn't suspended.
+func (t *Terminal) Resume() bool {
+	state := t.suspended
+	if state == nil {
+		return false
+	}
+	t.suspended = nil
+
+	t.SetTermIOs(state.termios)
+	if state.modes&altScreenMode != 0 {
+		t.AltScreen()
+	}
+	if state.modes&hiddenCursorMode != 0 {
+		t.HideCursor()
+	}
+	if state.modes&mouseMode != 0 {
+		t.EnableMouse()
+	}
+	if state.modes&mouseMoveMode != 0 {
+		t.EnableMouseMove()
+	}
+	if state.modes&mousePixelsMode != 0 {
+		t.EnableMousePixels()
+	}
+	if state.modes&focusEventsMode != 0 {
+		t.EnableFocusEvents()
+	}
+	if state.modes&bracketedPasteMode != 0 {
+		t.EnableBracketedPaste()
+	}
+	if state.kittyFlags != 0 {
+		t.EnableKittyKeyboard(state.kittyFlags)
+	}
+	t.Clear()
+	t.Flush()
+	return true
+}
+
+// Makes sure the terminal is restored however the program ends. Handles SIGTERM and
+// SIGHUP by restoring the terminal before dying of them, and Ctrl+Z by suspending and
+// resuming, calling resumed afterwards so the screen can be redrawn. Signals are handled
+// on another goroutine, holding Lock, so hold it while writing too. Defer the function
+// it returns at the top of main, which restores the terminal when main returns or panics:
+//
+//	defer term.Guard(canvas.Invalidate)()
+//
+// Panics in other goroutines can't be caught, and still leave the terminal as it was.
+func (t *Terminal) Guard(resumed func()) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGTSTP, syscall.SIGCONT)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case sig := <-signals:
+				if t.handleSignal(sig) && resumed != nil {
+					resumed()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		err := recover()
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+			// Let a signal being handled finish first, so it can't write over this
+			<-stopped
+			t.Restore()
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Suspends, resumes or restores the terminal for a signal, holding Lock.
+// Returns true if it resumed.
+func (t *Terminal) handleSignal(sig os.Signal) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	switch sig {
+	case syscall.SIGTSTP:
+		t.Suspend()
+	case syscall.SIGCONT:
+		return t.Resume()
+	default:
+		// Die of the signal as if it wasn't handled
+		t.Restore()
+		signal.Reset(sig)
+		syscall.Kill(syscall.Getpid(), sig.(syscall.Signal))
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package terminal
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRestore(t *testing.T) {
+	term, recorder := newRecordedTerminal()
+	term.AltScreen()
+	term.HideCursor()
+	term.EnableMouse()
+	term.EnableFocusEvents()
+	term.EnableKittyKeyboard(KittyKeyState)
+	term.Flush()
+	recorder.writes = nil
+
+	// Half way through drawing a frame
+	term.BeginFrame()
+	term.Write("partial frame")
+	term.Restore()
+
+	restored := strings.Join(recorder.writes, "")
+	expected := "\x1b[<u\x1b[?1004l\x1b[?1006l\x1b[?1002l\x1b[?1000l\x1b[0m\x1b[?25h\x1b[?1049l"
+	if restored != expected {
+		t.Errorf("Expected %q, got %q", expected, restored)
+	}
+
+	recorder.writes = nil
+	term.Restore()
+	if len(recorder.writes) != 0 {
+		t.Errorf("Expected restoring twice to do nothing, got %q", recorder.writes)
+	}
+}
+
+func TestResume(t *testing.T) {
+	term, recorder := newRecordedTerminal()
+	if term.Resume() {
+		t.Errorf("Expected nothing to resume")
+	}
+
+	term.suspended = &suspendedState{
+		termios:    term.TermIOs(),
+		modes:      altScreenMode | mouseMode,
+		kittyFlags: KittyDisambiguate,
+	}
+	if !term.Resume() {
+		t.Fatalf("Expected to resume")
+	}
+
+	resumed := strings.Join(recorder.writes, "")
+	expected := "\x1b[?1049h\x1b[?1000h\x1b[?1002h\x1b[?1006h\x1b[>1u\x1b[2J"
+	if resumed != expected {
+		t.Errorf("Expected %q, got %q", expected, resumed)
+	}
+	if term.modes != altScreenMode|mouseMode || term.KittyKeyboard() != KittyDisambiguate {
+		t.Errorf("Expected modes to be turned back on, got %b and %d", term.modes, term.KittyKeyboard())
+	}
+}
+
+func TestGuardRestoresAfterPanic(t *testing.T) {
+	term, recorder := newRecordedTerminal()
+
+	func() {
+		defer func() {
+			if err := recover(); err != "oops" {
+				t.Errorf("Expected the panic to carry on after restoring, got %v", err)
+			}
+		}()
+		defer term.Guard(nil)()
+
+		term.AltScreen()
+		panic("oops")
+	}()
+
+	if output := strings.Join(recorder.writes, ""); !strings.HasSuffix(output, "\x1b[?1049l") {
+		t.Errorf("Expected the main screen to be restored, got %q", output)
+	}
+}
+
+func TestGuardTwice(t *testing.T) {
+	term, recorder := newRecordedTerminal()
+	restore := term.Guard(nil)
+	term.AltScreen()
+	restore()
+
+	recorder.writes = nil
+	restore()
+	if len(recorder.writes) != 0 {
+		t.Errorf("Expected restoring twice to do nothing, got %q", recorder.writes)
+	}
+}
+
+func TestSignalsWaitForLock(t *testing.T) {
+	term, _ := newRecordedTerminal()
+	term.Lock()
+
+	handled := make(chan bool)
+	go func() {
+		handled <- term.handleSignal(syscall.SIGCONT)
+	}()
+	select {
+	case <-handled:
+		t.Fatalf("Expected the signal to wait while the terminal is locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	term.Unlock()
+	select {
+	case resumed := <-handled:
+		if resumed {
+			t.Errorf("Expected nothing to resume")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the signal to be handled after unlocking")
+	}
+}
+
+func TestSuspendKey(t *testing.T) {
+	term, _ := newRecordedTerminal()
+	stopped := make(chan struct{}, 1)
+	stopProcess = func() { stopped <- struct{}{} }
+	defer func() { stopProcess = func() { syscall.Kill(syscall.Getpid(), syscall.SIGSTOP) } }()
+	defer term.Guard(nil)()
+
+	if event, _, _ := decodeEvent([]byte("\x1b[122u"), false); SuspendKey(event) {
+		t.Errorf("Expected z without Ctrl not to suspend")
+	}
+
+	// Ctrl+Z reported as a key
+	event, _, ok := decodeEvent([]byte("\x1b[122;5u"), false)
+	if !ok || !SuspendKey(event) {
+		t.Fatalf("Expected Ctrl+Z to suspend, got %+v", event)
+	}
+	select {
+	case <-stopped:
+		term.Lock()
+		if term.suspended == nil {
+			t.Errorf("Expected the terminal to be suspended")
+		}
+		term.Unlock()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the process to be stopped")
+	}
+}
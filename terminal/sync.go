@@ -0,0 +1,221 @@
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DEC private mode that makes the terminal hold off drawing until an update is finished.
+// See https://gist.github.com/christianparpart/d8a62cc1ab659194337d73e399004036
+const SynchronizedOutputMode = 2026
+
+// How long to wait for the terminal to answer a query
+const queryTimeout = 200 * time.Millisecond
+
+// What a terminal reports about a mode, in answer to DECRQM
+type ModeStatus uint8
+
+const (
+	// No answer, or the terminal doesn't know the mode
+	ModeNotRecognized ModeStatus = iota
+	ModeSet
+	ModeReset
+	ModePermanentlySet
+	ModePermanentlyReset
+)
+
+// Checks if a mode can be turned on and off
+func (s ModeStatus) IsSupported() bool {
+	return s == ModeSet || s == ModeReset
+}
+
+// Wraps frames in synchronized updates, so the terminal draws each one all at once
+func (t *Terminal) SetSynchronizedOutput(enabled bool) {
+	t.syncOutput = enabled
+}
+
+func (t *Terminal) SynchronizedOutput() bool {
+	return t.syncOutput
+}
+
+// Asks the terminal if it supports synchronized output, and uses it if so.
+// Call it before anything else reads input, as it reads the terminal's answer from stdin.
+func (t *Terminal) DetectSynchronizedOutput() bool {
+	t.syncOutput = t.QueryMode(SynchronizedOutputMode, queryTimeout).IsSupported()
+	return t.syncOutput
+}
+
+// Asks the terminal about a DEC private mode with DECRQM, waiting up to timeout for an answer
+func (t *Terminal) QueryMode(mode int, timeout time.Duration) ModeStatus {
+	return modeStatus(t.query(fmt.Sprintf("\x1b[?%d$p", mode), timeout), mode)
+}
+
+// Finds the DECRPM report about a mode: CSI ? <mode> ; <status> $ y
+func modeStatus(reports []queryReport, mode int) ModeStatus {
+	prefix := strconv.Itoa(mode) + ";"
+	for _, report := range reports {
+		if report.Final != 'y' || !strings.HasPrefix(report.Params, prefix) || !strings.HasSuffix(report.Params, "$") {
+			continue
+		}
+		value, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(report.Params, prefix), "$"))
+		if value >= int(ModeSet) && value <= int(ModePermanentlyReset) {
+			return ModeStatus(value)
+		}
+	}
+	return ModeNotRecognized
+}
+
+// A report sent in answer to a query: CSI ? <params> <final>, or CSI <params> t about the window
+type queryReport struct {
+	Params string
+	Final  byte
+}
+
+// Sends a query and waits up to timeout for the reports that answer it.
+// The query is followed by a request for the device attributes, which every terminal
+// answers, so terminals that ignore the query don't have to wait for the whole timeout.
+// It reads the answer from stdin, so it can't be used once Events or NextEvent has
+// started reading input, and returns nothing if it has.
+func (t *Terminal) query(request string, timeout time.Duration) []queryReport {
+	if t.inputReady != nil {
+		return nil
+	}
+
+	// Read without waiting for a whole line, and without echoing the answer
+	old := t.TermIOs()
+	termios := old
+	termios.Lflag &^= syscall.ICANON | syscall.ECHO
+	termios.Cc[syscall.VMIN] = 0
+	termios.Cc[syscall.VTIME] = 1
+	t.SetTermIOs(termios)
+	defer t.SetTermIOs(old)
+
+	t.Write(request + "\x1b[c")
+	t.Flush()
+
+	// Anything already read comes first
+	data, _ := t.stdin.Peek(t.stdin.Buffered())
+	data = append([]byte{}, data...)
+
+	data = readQueryAnswer(os.Stdin, data, timeout)
+
+	// Keep any key presses that arrived while waiting
+	reports, _, rest := parseQueryReports(data)
+	t.stdin = *bufio.NewReaderSize(io.MultiReader(bytes.NewReader(rest), os.Stdin), 64)
+	return reports
+}
+
+// Reads until the device attributes that end the answer to a query arrive, or timeout passes.
+// Reads that time out with nothing to return give io.EOF, so that means keep waiting.
+func readQueryAnswer(reader io.Reader, data []byte, timeout time.Duration) []byte {
+	buf := make([]byte, 64)
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if _, answered, _ := parseQueryReports(data); answered {
+			break
+		}
+		n, err := reader.Read(buf)
+		data = append(data, buf[:n]...)
+		if err != nil && err != io.EOF {
+			break
+		}
+		if n == 0 {
+			// Don't spin on input that isn't a terminal, which gives io.EOF straight away
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return data
+}
+
+// Finds the reports answering a query in some input, and whether the device attributes
+// that follow them have arrived. Returns the input that's not part of either.
+func parseQueryReports(data []byte) (reports []queryReport, answered bool, rest []byte) {
+	rest = []byte{}
+	for len(data) > 0 {
+		start := 3
+		if !bytes.HasPrefix(data, []byte("\x1b[?")) {
+			if !bytes.HasPrefix(data, []byte("\x1b[")) {
+				rest = append(rest, data[0])
+				data = data[1:]
+				continue
+			}
+			start = 2
+		}
+
+		// Parameters and intermediate bytes, then a final byte
+		end := start
+		for end < len(data) && data[end] >= 0x20 && data[end] < 0x40 {
+			end++
+		}
+		if end == len(data) {
+			// Not all here yet
+			return reports, answered, append(rest, data...)
+		}
+
+		report := queryReport{Params: string(data[start:end]), Final: data[end]}
+		if start == 2 && report.Final != 't' {
+			// Not a report, such as a key pressed while waiting
+			rest = append(rest, data[:end+1]...)
+		} else if report.Final == 'c' {
+			answered = true
+		} else {
+			reports = append(reports, report)
+		}
+		data = data[end+1:]
+	}
+
+	return reports, answered, rest
+}
+
+// Collects everything written until EndFrame, so the whole frame is sent in one write.
+// Frames can be nested, only the outermost one is sent.
+func (t *Terminal) BeginFrame() {
+	if t.frameDepth == 0 {
+		t.frame.Reset()
+		if t.syncOutput {
+			t.frame.WriteString("\x1b[?2026h")
+		}
+		t.frameStart = t.frame.Len()
+	}
+	t.frameDepth += 1
+}
+
+// Sends the frame started by BeginFrame, as a synchronized update if the terminal supports it
+func (t *Terminal) EndFrame() {
+	if t.frameDepth == 0 {
+		return
+	}
+	t.frameDepth -= 1
+	if t.frameDepth > 0 {
+		return
+	}
+	if t.frame.Len() == t.frameStart {
+		// Nothing drawn, but send anything written before the frame
+		t.stdout.Flush()
+		return
+	}
+	if t.frameStart > 0 {
+		t.frame.WriteString("\x1b[?2026l")
+	}
+
+	// Writing to an empty buffer, then flushing, always makes a single write
+	t.stdout.Flush()
+	t.stdout.Write(t.frame.Bytes())
+	t.stdout.Flush()
+	t.frame.Reset()
+}
+
+// Where writes go: the current frame, or straight to the output buffer
+func (t *Terminal) output() io.Writer {
+	if t.frameDepth > 0 {
+		return &t.frame
+	}
+	return &t.stdout
+}
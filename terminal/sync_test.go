@@ -0,0 +1,151 @@
+package terminal
+
+import (
+	"bufio"
+	"io"
+	"testing"
+	"time"
+)
+
+// Keeps each write separately, to check frames aren't split up
+type writeRecorder struct {
+	writes []string
+}
+
+func (w *writeRecorder) Write(data []byte) (int, error) {
+	w.writes = append(w.writes, string(data))
+	return len(data), nil
+}
+
+func newRecordedTerminal() (*Terminal, *writeRecorder) {
+	recorder := &writeRecorder{}
+	term := &Terminal{
+		width:  80,
+		height: 24,
+		stdout: *bufio.NewWriterSize(recorder, 16),
+	}
+	return term, recorder
+}
+
+func TestParseQueryReports(t *testing.T) {
+	tests := []struct {
+		input    string
+		status   ModeStatus
+		answered bool
+		rest     string
+	}{
+		{"", ModeNotRecognized, false, ""},
+		{"\x1b[?2026;2$y\x1b[?62;22c", ModeReset, true, ""},
+		{"\x1b[?2026;1$y", ModeSet, false, ""},
+		{"\x1b[?2026;4$y\x1b[?1;2c", ModePermanentlyReset, true, ""},
+		{"\x1b[?2026;0$y\x1b[?1;2c", ModeNotRecognized, true, ""},
+		// Terminals that don't understand DECRQM only answer the device attributes
+		{"\x1b[?65;1;9c", ModeNotRecognized, true, ""},
+		// A report about another mode
+		{"\x1b[?1049;1$y\x1b[?1c", ModeNotRecognized, true, ""},
+		// Key presses before and between the answers are kept
+		{"ab\x1b[?2026;2$yc\x1b[?62c", ModeReset, true, "abc"},
+		{"\x1b[A\x1b[?2026;1$y", ModeSet, false, "\x1b[A"},
+		// An answer that hasn't finished arriving
+		{"\x1b[?2026;", ModeNotRecognized, false, "\x1b[?2026;"},
+	}
+
+	for _, test := range tests {
+		reports, answered, rest := parseQueryReports([]byte(test.input))
+		status := modeStatus(reports, SynchronizedOutputMode)
+		if status != test.status || answered != test.answered || string(rest) != test.rest {
+			t.Errorf("Parsing %q: expected %v %v %q, got %v %v %q", test.input, test.status, test.answered, test.rest, status, answered, rest)
+		}
+	}
+}
+
+func TestFrameIsWrittenOnce(t *testing.T) {
+	term, recorder := newRecordedTerminal()
+	term.Write("before")
+
+	term.BeginFrame()
+	for i := 0; i < 100; i++ {
+		term.Write("frame %d;", i)
+		term.Flush()
+	}
+	if len(recorder.writes) != 0 {
+		t.Fatalf("Expected nothing to be written during a frame, got %q", recorder.writes)
+	}
+	term.EndFrame()
+
+	if len(recorder.writes) != 2 {
+		t.Fatalf("Expected 2 writes, got %d", len(recorder.writes))
+	}
+	if recorder.writes[0] != "before" {
+		t.Errorf("Expected output before the frame to be written first, got %q", recorder.writes[0])
+	}
+	if len(recorder.writes[1]) < 16 || recorder.writes[1][:8] != "frame 0;" {
+		t.Errorf("Expected the frame in one write, got %q", recorder.writes[1])
+	}
+}
+
+func TestSynchronizedFrame(t *testing.T) {
+	term, recorder := newRecordedTerminal()
+	term.SetSynchronizedOutput(true)
+
+	term.Draw(func() {
+		term.WriteBytes([]byte("outer "))
+		term.Draw(func() {
+			term.WriteBytes([]byte("inner"))
+		})
+	})
+
+	expected := []string{"\x1b[?2026houter inner\x1b[?2026l"}
+	if len(recorder.writes) != 1 || recorder.writes[0] != expected[0] {
+		t.Errorf("Expected %q, got %q", expected, recorder.writes)
+	}
+
+	// Empty frames aren't sent at all
+	term.Draw(func() {})
+	if len(recorder.writes) != 1 {
+		t.Errorf("Expected an empty frame to write nothing, got %q", recorder.writes[1:])
+	}
+}
+
+// Answers reads with nothing, like a terminal that hasn't answered yet, then with replies
+type slowReader struct {
+	empty   int
+	replies []string
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.empty > 0 {
+		r.empty--
+		return 0, io.EOF
+	}
+	if len(r.replies) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.replies[0])
+	r.replies = r.replies[1:]
+	return n, nil
+}
+
+func TestReadQueryAnswer(t *testing.T) {
+	// A slow answer still arrives, as empty reads don't end the wait
+	reader := &slowReader{empty: 3, replies: []string{"\x1b[?2026;", "2$y\x1b[?62c", "x"}}
+	data := readQueryAnswer(reader, nil, time.Second)
+	if string(data) != "\x1b[?2026;2$y\x1b[?62c" {
+		t.Errorf("Expected the whole answer, got %q", data)
+	}
+
+	// Terminals that never answer are given up on
+	start := time.Now()
+	readQueryAnswer(&slowReader{}, nil, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected to wait for the timeout, waited %v", elapsed)
+	}
+}
+
+func TestQueryAfterReading(t *testing.T) {
+	term, _ := newPipedTerminal()
+	term.inputReady = make(chan struct{}, 1)
+	if reports := term.query("\x1b[?u", time.Second); reports != nil {
+		t.Errorf("Expected no query once input is being read, got %v", reports)
+	}
+}
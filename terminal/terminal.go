@@ -2,9 +2,12 @@ package terminal
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
+	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
@@ -16,18 +19,53 @@ type WinSize struct {
 }
 
 type Terminal struct {
+	// Held while writing, see Lock
+	mux sync.Mutex
+	// Guards the size, which Events updates when the terminal is resized
+	sizeMux       sync.Mutex
 	width, height int
-	stdout        bufio.Writer
-	stdin         bufio.Reader
+	// Size in pixels from the kernel, and of a cell from DetectCellSize, or 0 if unknown
+	pixelWidth, pixelHeight int
+	cellWidth, cellHeight   float64
+	// Set by SetCellAspect, or 0 to work it out
+	cellAspect float64
+	stdout     bufio.Writer
+	stdin      bufio.Reader
+	colorMode  ColorMode
+	// Input read in the background but not yet taken, and why reading stopped
+	inputMux   sync.Mutex
+	unread     []byte
+	readErr    error
+	inputReady chan struct{}
+	// Input taken but not yet decoded, and the error that ended it
+	pending       []byte
+	inputErr      error
+	escapeTimeout time.Duration
+	// Guards the keyboard flags and modes turned on, which decoding events reads
+	modesMux   sync.Mutex
+	kittyFlags KittyKeyboardFlags
+	// Modes turned on, and the settings from before they were, for Restore
+	modes        terminalModes
+	savedTermios *syscall.Termios
+	suspended    *suspendedState
+	// Wrap frames in synchronized updates
+	syncOutput bool
+	// Output collected between BeginFrame and EndFrame
+	frame      bytes.Buffer
+	frameDepth int
+	frameStart int
 }
 
-func NewTerminal() Terminal {
-	term := Terminal{
-		width:  16,
-		height: 16,
-		stdout: *bufio.NewWriterSize(os.Stdout, 4096),
-		stdin:  *bufio.NewReaderSize(os.Stdin, 64),
+func NewTerminal() *Terminal {
+	term := &Terminal{
+		width:      16,
+		height:     16,
+		stdout:     *bufio.NewWriterSize(os.Stdout, 4096),
+		stdin:      *bufio.NewReaderSize(os.Stdin, 64),
+		colorMode:  DetectColorMode(),
+		cellAspect: detectCellAspect(os.Getenv),
 	}
+	term.SaveState()
 	term.UpdateSize()
 	return term
 }
@@ -42,35 +80,63 @@ func (t *Terminal) UpdateSize() {
 		uintptr(unsafe.Pointer(&winSize)),
 	)
 
+	t.sizeMux.Lock()
+	defer t.sizeMux.Unlock()
 	t.width = int(winSize.cols)
 	t.height = int(winSize.rows)
+	t.pixelWidth = int(winSize.xpixels)
+	t.pixelHeight = int(winSize.ypixels)
 }
 
 func (t *Terminal) Size() (int, int) {
+	t.sizeMux.Lock()
+	defer t.sizeMux.Unlock()
 	return t.width, t.height
 }
 
 func (t *Terminal) Width() int {
-	return t.width
+	width, _ := t.Size()
+	return width
 }
 
 func (t *Terminal) Height() int {
-	return t.height
+	_, height := t.Size()
+	return height
 }
 
+// Stops other goroutines writing until Unlock. Guard holds it while handling signals,
+// and Canvas.Present while sending a frame, so anything else writing while Guard is
+// running needs to hold it too. Unlock it with defer, so a panic doesn't leave it held.
+func (t *Terminal) Lock() {
+	t.mux.Lock()
+}
+
+func (t *Terminal) Unlock() {
+	t.mux.Unlock()
+}
+
+// Draws a frame, sending it all at once
 func (t *Terminal) Draw(callback func()) {
+	t.BeginFrame()
 	callback()
-	t.Flush()
+	t.EndFrame()
 }
 
+// Sends everything written so far. Does nothing while drawing a frame, which is sent by EndFrame.
 func (t *Terminal) Flush() {
-	t.stdout.Flush()
+	if t.frameDepth == 0 {
+		t.stdout.Flush()
+	}
 }
 
 func (t *Terminal) Write(format string, a ...interface{}) {
-	fmt.Fprintf(&t.stdout, format, a...)
+	fmt.Fprintf(t.output(), format, a...)
+}
+
+func (t *Terminal) WriteBytes(data []byte) {
+	t.output().Write(data)
 }
 
 func (t *Terminal) WriteRune(char rune) {
-	fmt.Fprint(&t.stdout, string(char))
+	fmt.Fprint(t.output(), string(char))
 }
@@ -0,0 +1,97 @@
+package terminal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Magic numbers at the start of compiled terminfo files.
+// The extended format stores numbers as 32 bits instead of 16.
+const (
+	terminfoMagic         = 0432
+	terminfoExtendedMagic = 01036
+)
+
+// Position of `colors` in the numeric capabilities. See term(5).
+const terminfoMaxColors = 13
+
+// Directories searched for compiled terminfo entries, in order
+func terminfoDirs() []string {
+	dirs := []string{}
+	if dir := os.Getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	for _, dir := range strings.Split(os.Getenv("TERMINFO_DIRS"), ":") {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return append(dirs, "/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo", "/usr/lib/terminfo")
+}
+
+// Reads the number of colours a terminal supports from its terminfo entry
+func terminfoColors(term string) (int, error) {
+	if term == "" || strings.ContainsAny(term, "/\\") {
+		return 0, fmt.Errorf("invalid terminal name %q", term)
+	}
+
+	for _, dir := range terminfoDirs() {
+		// Entries are sorted into directories by first letter, or its hex code on macOS
+		for _, sub := range []string{term[:1], fmt.Sprintf("%x", term[0])} {
+			data, err := ioutil.ReadFile(filepath.Join(dir, sub, term))
+			if err == nil {
+				return parseTerminfoColors(data)
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("no terminfo entry for %q", term)
+}
+
+// Finds the `colors` capability in a compiled terminfo file.
+// Returns -1 if the terminal doesn't have it.
+func parseTerminfoColors(data []byte) (int, error) {
+	if len(data) < 12 {
+		return 0, fmt.Errorf("terminfo is too short")
+	}
+	header := make([]int, 6)
+	for i := range header {
+		header[i] = int(int16(binary.LittleEndian.Uint16(data[i*2:])))
+	}
+
+	numberSize := 0
+	switch header[0] {
+	case terminfoMagic:
+		numberSize = 2
+	case terminfoExtendedMagic:
+		numberSize = 4
+	default:
+		return 0, fmt.Errorf("terminfo has unknown magic number %#o", header[0])
+	}
+	namesSize, boolCount, numberCount := header[1], header[2], header[3]
+
+	// Numbers come after the names and booleans, aligned to an even byte
+	offset := 12 + namesSize + boolCount
+	if offset%2 == 1 {
+		offset += 1
+	}
+	if numberCount <= terminfoMaxColors {
+		return -1, nil
+	}
+	offset += terminfoMaxColors * numberSize
+	if offset+numberSize > len(data) {
+		return 0, fmt.Errorf("terminfo is truncated")
+	}
+
+	if numberSize == 2 {
+		return int(int16(binary.LittleEndian.Uint16(data[offset:]))), nil
+	}
+	return int(int32(binary.LittleEndian.Uint32(data[offset:]))), nil
+}
@@ -0,0 +1,132 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	. "math"
+	"os"
+	. "tri/geom"
+)
+
+// How a texture is sampled between its pixels
+type Filter uint8
+
+const (
+	// Use the closest pixel, for a blocky look
+	NearestFilter Filter = iota
+	// Blend the four closest pixels
+	BilinearFilter
+)
+
+// What happens to UVs outside 0.0 to 1.0
+type Wrap uint8
+
+const (
+	// Tile the texture
+	RepeatWrap Wrap = iota
+	// Stretch the edge pixels
+	ClampWrap
+)
+
+// An image that can be stretched over polygons
+type Texture struct {
+	Width  int
+	Height int
+	// RGB colours, one row after another, starting at the top
+	Pixels []Vector3
+	Filter Filter
+	Wrap   Wrap
+}
+
+// Copies an image into a new texture
+func NewTexture(img image.Image) *Texture {
+	bounds := img.Bounds()
+	t := &Texture{
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		Pixels: make([]Vector3, bounds.Dx()*bounds.Dy()),
+		Filter: BilinearFilter,
+		Wrap:   RepeatWrap,
+	}
+
+	for y := 0; y < t.Height; y++ {
+		for x := 0; x < t.Width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			t.Pixels[x+y*t.Width] = Vector3{
+				float64(r) / 0xffff,
+				float64(g) / 0xffff,
+				float64(b) / 0xffff,
+			}
+		}
+	}
+
+	return t
+}
+
+// Loads a PNG or JPEG image as a texture
+func NewTextureFromPath(path string) (*Texture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return NewTexture(img), nil
+}
+
+// Colour of the texture at a UV coordinate. U goes left to right, V goes bottom to top.
+func (t *Texture) Sample(uv Vector2) Vector3 {
+	if t.Width == 0 || t.Height == 0 {
+		return Vector3{}
+	}
+
+	// Pixel coordinates, with the top left of the image at 0,0
+	x := uv[0] * float64(t.Width)
+	y := (1 - uv[1]) * float64(t.Height)
+
+	if t.Filter == NearestFilter {
+		return t.Pixel(int(Floor(x)), int(Floor(y)))
+	}
+
+	// Pixel centres are at half coordinates
+	x, y = x-0.5, y-0.5
+	x0, y0 := Floor(x), Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+
+	top := t.Pixel(ix, iy).Scale(1 - fx).Add(t.Pixel(ix+1, iy).Scale(fx))
+	bottom := t.Pixel(ix, iy+1).Scale(1 - fx).Add(t.Pixel(ix+1, iy+1).Scale(fx))
+	return top.Scale(1 - fy).Add(bottom.Scale(fy))
+}
+
+// Colour of a single pixel. Coordinates outside the texture are wrapped.
+func (t *Texture) Pixel(x, y int) Vector3 {
+	x = t.wrap(x, t.Width)
+	y = t.wrap(y, t.Height)
+	return t.Pixels[x+y*t.Width]
+}
+
+func (t *Texture) wrap(i, size int) int {
+	if t.Wrap == ClampWrap {
+		if i < 0 {
+			return 0
+		}
+		if i >= size {
+			return size - 1
+		}
+		return i
+	}
+
+	i %= size
+	if i < 0 {
+		i += size
+	}
+	return i
+}
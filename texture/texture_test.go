@@ -0,0 +1,93 @@
+package texture
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	. "tri/geom"
+)
+
+// A 2x2 checkerboard, black in the top left
+func newChecker() *Texture {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(1, 0, color.White)
+	img.Set(0, 1, color.White)
+	img.Set(0, 0, color.Black)
+	img.Set(1, 1, color.Black)
+	return NewTexture(img)
+}
+
+func assertColor(t *testing.T, name string, result, expected Vector3) {
+	for i := range result {
+		if math.Abs(result[i]-expected[i]) > 0.0001 {
+			t.Errorf("%s: expected %v, got %v", name, expected, result)
+			return
+		}
+	}
+}
+
+func TestTextureSampleNearest(t *testing.T) {
+	tex := newChecker()
+	tex.Filter = NearestFilter
+
+	black, white := Vector3{0, 0, 0}, Vector3{1, 1, 1}
+	assertColor(t, "top left", tex.Sample(Vector2{0.1, 0.9}), black)
+	assertColor(t, "top right", tex.Sample(Vector2{0.9, 0.9}), white)
+	assertColor(t, "bottom left", tex.Sample(Vector2{0.1, 0.1}), white)
+	assertColor(t, "repeated", tex.Sample(Vector2{1.1, -0.1}), black)
+
+	tex.Wrap = ClampWrap
+	assertColor(t, "clamped", tex.Sample(Vector2{1.1, -0.1}), black)
+	assertColor(t, "clamped far away", tex.Sample(Vector2{-5, 0.1}), white)
+}
+
+func TestTextureSampleBilinear(t *testing.T) {
+	tex := newChecker()
+
+	grey := Vector3{0.5, 0.5, 0.5}
+	assertColor(t, "centre", tex.Sample(Vector2{0.5, 0.5}), grey)
+	assertColor(t, "pixel centre", tex.Sample(Vector2{0.25, 0.75}), Vector3{0, 0, 0})
+	assertColor(t, "halfway across", tex.Sample(Vector2{0.5, 0.75}), grey)
+	// Blends with the opposite edge when repeating
+	assertColor(t, "edge", tex.Sample(Vector2{0, 0.75}), grey)
+
+	tex.Wrap = ClampWrap
+	assertColor(t, "clamped edge", tex.Sample(Vector2{0, 0.75}), Vector3{0, 0, 0})
+}
+
+func TestNewTextureFromPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tri-texture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	img := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	img.Set(2, 0, color.RGBA{0xff, 0x00, 0x00, 0xff})
+	path := filepath.Join(dir, "red.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, img)
+	f.Close()
+
+	tex, err := NewTextureFromPath(path)
+	if err != nil {
+		t.Fatalf("Failed to load texture: %v", err)
+	}
+	if tex.Width != 3 || tex.Height != 1 {
+		t.Errorf("Texture is the wrong size: %dx%d", tex.Width, tex.Height)
+	}
+	assertColor(t, "loaded pixel", tex.Pixel(2, 0), Vector3{1, 0, 0})
+
+	ioutil.WriteFile(path, []byte("not a png"), 0644)
+	if _, err := NewTextureFromPath(path); err == nil {
+		t.Errorf("Expected an error loading a broken image")
+	}
+}
@@ -3,15 +3,13 @@ package window
 import (
 	"os"
 	"os/signal"
-	"syscall"
 	. "tri/canvas"
-	. "tri/geom"
 	. "tri/renderer"
 	. "tri/terminal"
 )
 
 type Window struct {
-	Terminal Terminal
+	Terminal *Terminal
 	Canvas   Canvas
 	Renderer Renderer
 }
@@ -19,53 +17,46 @@ type Window struct {
 func New() Window {
 	term := NewTerminal()
 	width, height := term.Size()
-	canvas := NewCanvas(width, height)
-
-	renderer := Renderer{
-		Camera: Camera{
-			Projection: NewMatrix4Perspective(float64(width)/float64(height), 45, 0.1, 1000.0),
-			Transform: Transform{
-				Translation: Vector3{0, 0, 0},
-				Rotation:    Vector3{0, 0, 0},
-				Scaling:     Vector3{1, 1, 1},
-			},
-		},
-	}
+
+	camera := NewCamera(PerspectiveProjection)
+	camera.SetViewport(width, height, term.CellAspect())
 
 	return Window{
 		Terminal: term,
-		Canvas:   canvas,
-		Renderer: renderer,
+		Canvas:   NewCanvas(width, height),
+		Renderer: Renderer{Camera: camera},
 	}
 }
 
-func (w *Window) ListenForResize() {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGWINCH)
+// Fits the canvas and camera to a new size, for a ResizeEvent from Terminal.Events
+func (w *Window) Resize(width, height int) {
+	w.Canvas.Resize(width, height)
+	w.updateProjection()
+}
 
-	go func() {
-		for sig := range c {
-			switch sig {
-			// Terminal was resized
-			case syscall.SIGWINCH:
-				w.Terminal.UpdateSize()
-				width, height := w.Terminal.Size()
-				w.Canvas.Resize(width, height)
-				w.Renderer.Camera.Projection = NewMatrix4Perspective(float64(width)/float64(height), 45, 0.1, 1000.0)
-			}
-		}
-	}()
+// Switch between one or two pixels per cell
+func (w *Window) SetPixelMode(mode PixelMode) {
+	w.Canvas.SetPixelMode(mode)
+}
+
+// Match the camera's aspect ratio to the shape of the canvas on screen
+func (w *Window) updateProjection() {
+	w.Renderer.Camera.SetViewport(w.Canvas.Width, w.Canvas.Height, w.Terminal.CellAspect())
 }
 
 func (w *Window) Open() {
+	w.Terminal.Lock()
+	defer w.Terminal.Unlock()
 	w.Terminal.AltScreen()
 	w.Terminal.HideCursor()
 	w.Terminal.RawMode()
+	if w.Terminal.DetectCellSize() {
+		w.updateProjection()
+	}
 	w.Terminal.EnableMouse()
 	//w.Terminal.DisableCtrlC()
 	w.Terminal.Clear()
 	w.Terminal.Flush()
-	w.ListenForResize()
 
 	// Catch Ctrl+C
 	c := make(chan os.Signal, 1)
@@ -81,17 +72,15 @@ func (w *Window) Open() {
 	}()
 }
 
+// Puts the terminal back how it was before Open
 func (w *Window) Close() {
-	w.Terminal.ShowCursor()
-	w.Terminal.NormalMode()
-	w.Terminal.DisableMouse()
-	w.Terminal.EnableCtrlC()
-	w.Terminal.MainScreen()
-	w.Terminal.Flush()
+	w.Terminal.Lock()
+	defer w.Terminal.Unlock()
+	w.Terminal.Restore()
 }
 
 func (w *Window) Present() {
-	w.Canvas.Present(&w.Terminal)
+	w.Canvas.Present(w.Terminal)
 }
 
 func (w *Window) Clear() {
@@ -109,3 +98,9 @@ func (w *Window) DrawCanvas(x, y int, canvas *Canvas) {
 	defer w.Canvas.Unlock()
 	w.Canvas.DrawCanvas(x, y, canvas)
 }
+
+func (w *Window) DrawLines(drawable LineDrawable) int {
+	w.Canvas.Lock()
+	defer w.Canvas.Unlock()
+	return w.Renderer.RenderLines(&w.Canvas, drawable)
+}